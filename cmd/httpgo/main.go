@@ -1,22 +1,26 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
 	"flag"
 	"fmt"
+	"github.com/gofrs/flock"
 	"httpgo/pkg/fingerprint"
 	"httpgo/pkg/httpgo"
+	"httpgo/pkg/screenshot"
+	"httpgo/pkg/sink"
 	"httpgo/pkg/utils"
+	"io"
 	"log"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 )
 
-const (
+// 终端颜色，JSON输出模式下置空
+var (
 	reset = "\033[0m"
 	green = "\033[32m"
 	red   = "\033[31m"
@@ -34,10 +38,34 @@ func main() {
 	thead := flag.Int("thead", 20, "并发数")
 	fingers := flag.String("fingers", "fingers.json", "指纹文件")
 	hash := flag.String("hash", "", "计算hash")
-	output := flag.String("output", "output", "输出结果文件夹名称,不用加后缀(包含csv,json,html文件)")
+	output := flag.String("output", "output", "输出结果文件夹名称,不用加后缀(包含csv,json,jsonl,html文件)")
+	outputFormats := flag.String("o", "csv,json,jsonl,html", "输出文件格式，可任意组合 csv,json,jsonl,html（html 报告依赖 json 文件，选择 html 时会同时生成 json）")
 	//outputhtml := flag.String("outputhtml", "report.html", "输出文件")
 	server := flag.String("server", "", "指定需要远程访问的output的文件夹名称，启动web服务，自带随机密码，增加安全性")
 	checkf := flag.Bool("check", false, "检查新添加指纹规则的合规性")
+	skipBadRules := flag.Bool("skip-bad-rules", false, "跳过有语法错误的指纹规则继续扫描，默认存在错误时退出")
+	maxConnsPerHost := flag.Int("max-conns-per-host", 0, "每个主机的最大连接数，0为不限制")
+	maxIdleConns := flag.Int("max-idle-conns", 0, "连接池最大空闲连接数，0为与并发数相同")
+	rate := flag.Float64("rate", 0, "全局每秒最大请求数（包含favicon、路径探测等请求），0为不限制")
+	perHostRate := flag.Float64("per-host-rate", 0, "每个IP每秒最大请求数，指向同一IP的多个主机共用限制，0为不限制")
+	perHostConcurrency := flag.Int("per-host-concurrency", 0, "每个IP的最大并发请求数，0为不限制")
+	retries := flag.Int("retries", 0, "GET/HEAD 请求超时、连接被重置或返回5xx时的重试次数")
+	retryBackoff := flag.Duration("retry-backoff", 500*time.Millisecond, "第一次重试前的等待时间，之后每次翻倍并加入随机抖动")
+	followRedirects := flag.String("follow-redirects", "all", "跳转跟随策略：none（不跟随）、same-host（只跟随同主机）、all（全部跟随）")
+	maxRedirects := flag.Int("max-redirects", 10, "最大跳转次数")
+	portsFlag := flag.String("ports", "", "与不带端口的目标组合的端口列表，如 80,443,8080-8090")
+	bothSchemes := flag.Bool("both-schemes", false, "对不带协议的目标（host、host:port）同时探测 https 和 http，并分别输出结果")
+	followHTMLRedirects := flag.Bool("follow-html-redirects", false, "跟随页面中的 meta refresh 和 JavaScript 跳转")
+	resume := flag.Bool("resume", false, "从-output目录中的断点文件继续上次中断的扫描，跳过已完成的目标并追加到已有结果")
+	http2 := flag.Bool("http2", false, "通过 ALPN 尝试使用 HTTP/2，默认只使用 HTTP/1.1")
+	jarmFlag := flag.Bool("jarm", false, "计算 HTTPS 目标的 JARM 指纹（每个目标额外发起10次TLS握手），可在指纹规则中用 jarm= 匹配")
+	certExpiryDays := flag.Int("cert-expiry-days", httpgo.DefaultCertExpiryDays, "证书在多少天内到期时记录为TLS问题，为0时只记录已过期的证书")
+	tlsCheck := flag.Bool("tls-check", false, "探测 HTTPS 目标是否接受 TLS 1.0/1.1 和 RC4、3DES 密码套件（每个目标额外发起4次TLS握手）")
+	noScreenshot := flag.Bool("no-screenshot", false, "不截图")
+	browserFlag := flag.String("browser", "", "用于截图的 Chromium/Chrome 路径，为空时自动查找，未找到时不截图")
+	screenshotTabs := flag.Int("screenshot-tabs", screenshot.DefaultTabs, "截图时同时打开的浏览器标签页数")
+	screenshotTimeout := flag.Duration("screenshot-timeout", screenshot.DefaultTimeout, "截图时等待页面加载的时间，超时后截取已加载的内容")
+	jsonOutput := flag.Bool("json", false, "以 JSON lines 格式将结果逐行输出到标准输出，不显示字符画和颜色，便于与 jq 等工具组合")
 
 	//取当前路径
 	dir, err := os.Getwd()
@@ -45,8 +73,17 @@ func main() {
 		log.Fatal(err)
 	}
 
-	//设置运行输出符号画httpgo
-	fmt.Println(`
+	// 解析命令行标志
+	flag.Parse()
+
+	// JSON输出模式下标准输出只输出结果，提示信息输出到标准错误
+	info := io.Writer(os.Stdout)
+	if *jsonOutput {
+		info = os.Stderr
+		reset, green, red = "", "", ""
+	} else {
+		//设置运行输出符号画httpgo
+		fmt.Println(`
  _       _     _                           
 | |__   | |_  | |_   _ __     __ _    ___  
 | '_ \  | __| | __| | '_ \   / _' |  / _ \ 
@@ -55,14 +92,47 @@ func main() {
                     |_|      |___/  
 							Version: 1.2.3
 	`)
+	}
 
-	// 解析命令行标志
-	flag.Parse()
+	// 整个扫描共用一个客户端以复用连接
+	if *maxIdleConns == 0 {
+		*maxIdleConns = *thead
+	}
+	redirectPolicy, err := httpgo.ParseRedirectPolicy(*followRedirects)
+	if err != nil {
+		fmt.Fprintln(info, err)
+		return
+	}
+	client, err := httpgo.NewClient(httpgo.ClientOptions{
+		Proxy:               *proxyFlag,
+		Timeout:             *timeoutInt * time.Second,
+		MaxConnsPerHost:     *maxConnsPerHost,
+		MaxIdleConns:        *maxIdleConns,
+		MaxIdleConnsPerHost: 2,
+		Redirect:            redirectPolicy,
+		MaxRedirects:        *maxRedirects,
+		FollowHTMLRedirects: *followHTMLRedirects,
+		Rate:                *rate,
+		PerHostRate:         *perHostRate,
+		PerHostConcurrency:  *perHostConcurrency,
+		Retry: httpgo.RetryPolicy{
+			Retries: *retries,
+			Backoff: *retryBackoff,
+		},
+		HTTP2:          *http2,
+		JARM:           *jarmFlag,
+		CertExpiryDays: *certExpiryDays,
+		TLSCheck:       *tlsCheck,
+	})
+	if err != nil {
+		fmt.Fprintln(info, "Error parsing proxy URL:", err)
+		return
+	}
 
 	if *hash != "" {
-		hashx, err := httpgo.GetResponse(*hash, *proxyFlag, *timeoutInt)
+		hashx, err := client.GetResponse(context.Background(), *hash)
 		if err != nil {
-			fmt.Println("Error getting response:", err)
+			fmt.Fprintln(info, "Error getting response:", err)
 			return
 		}
 		ahash := utils.IconHash(hashx.Body)
@@ -84,15 +154,15 @@ func main() {
 			if err != nil {
 				log.Fatal(err)
 			}
-			fmt.Printf("----------------------------------------------------------------------------------\n")
-			fmt.Printf("已启动web服务，可直接访问下面链接，进行实时查看结果\n")
-			fmt.Printf("localhost：http://127.0.0.1:%d/%s.html\n", port, *server)
-			fmt.Printf("Serving：http://%s:%d/%s.html\n", ipadd, port, *server)
-			fmt.Printf("UserName: admin\n")
-			fmt.Printf("Password: %s\n", Spasswd)
-			fmt.Printf("一键访问：http://admin:%s@127.0.0.1:%d/%s.html\n", Spasswd, port, *server)
-			fmt.Printf("一键访问：http://admin:%s@%s:%d/%s.html\n", Spasswd, ipadd, port, *server)
-			fmt.Printf("----------------------------------------------------------------------------------\n")
+			fmt.Fprintf(info, "----------------------------------------------------------------------------------\n")
+			fmt.Fprintf(info, "已启动web服务，可直接访问下面链接，进行实时查看结果\n")
+			fmt.Fprintf(info, "localhost：http://127.0.0.1:%d/%s.html\n", port, *server)
+			fmt.Fprintf(info, "Serving：http://%s:%d/%s.html\n", ipadd, port, *server)
+			fmt.Fprintf(info, "UserName: admin\n")
+			fmt.Fprintf(info, "Password: %s\n", Spasswd)
+			fmt.Fprintf(info, "一键访问：http://admin:%s@127.0.0.1:%d/%s.html\n", Spasswd, port, *server)
+			fmt.Fprintf(info, "一键访问：http://admin:%s@%s:%d/%s.html\n", Spasswd, ipadd, port, *server)
+			fmt.Fprintf(info, "----------------------------------------------------------------------------------\n")
 			time.Sleep(3 * time.Second)
 			err = httpgo.ServeDirectoryWithAuth(newdir, "admin", Spasswd, port)
 			if err != nil {
@@ -103,7 +173,7 @@ func main() {
 
 	fingerlist, err := utils.LoadFingerprints(*fingers)
 	if err != nil {
-		fmt.Println("Error loading fingerprints:", err)
+		fmt.Fprintln(info, "Error loading fingerprints:", err)
 		return
 	}
 
@@ -112,138 +182,315 @@ func main() {
 		// 检查指纹规则
 		err := fingerprint.ValidateFingerprints(fingerlist)
 		if err != nil {
-			fmt.Println("Fingerprint validation error:", err)
+			fmt.Fprintln(info, "Fingerprint validation error:", err)
 		} else {
-			fmt.Println("Fingerprint validation successful")
+			fmt.Fprintln(info, "Fingerprint validation successful")
 		}
 		return
 	}
 
+	// 编译指纹规则，所有goroutine共享
+	rules, err := fingerprint.Compile(fingerlist)
+	if err != nil {
+		fmt.Fprintln(info, "Error compiling fingerprints:", err)
+		if !*skipBadRules {
+			fmt.Fprintln(info, "请修正指纹文件，或使用 -skip-bad-rules 跳过有错误的规则")
+			os.Exit(1)
+		}
+		fmt.Fprintf(info, "已跳过有错误的规则，使用其余 %d 条规则\n", len(rules.Rules))
+	}
+
+	scannerOpts := fingerprint.ScannerOptions{
+		Client:      client,
+		Rules:       rules,
+		Concurrency: *thead,
+	}
+	scanner, err := fingerprint.NewScanner(scannerOpts)
+	if err != nil {
+		fmt.Fprintln(info, "Error creating scanner:", err)
+		return
+	}
+
 	// 如果指定了url，则只处理单个url
 	if *urlFlag != "" {
-		if err != nil {
-			fmt.Println("Error getting url:", err)
-			return
+		targets := []string{*urlFlag}
+		if *bothSchemes {
+			targets = httpgo.WithSchemes(*urlFlag)
+		}
+		if !*jsonOutput {
+			fmt.Printf("%-20s %-10s %-20s %-10s %-10s\n", "URL", "Status", "Title", "CMS List", "Other List")
+		}
+		for _, target := range targets {
+			a, err := scanner.Scan(context.Background(), target)
+			if err != nil {
+				fmt.Fprintln(info, "Error getting fingerprint:", err)
+				return
+			}
+			if *jsonOutput {
+				sink.NewConsole(os.Stdout, true).Write(a)
+				continue
+			}
+			fmt.Printf("%-20s %-10d %-20s %s%-10s%s %s%-10s%s\n", a.Url, a.StatusCode, a.Title, green, utils.FormatCmsList(a.CmsList), reset, red, utils.FormatCmsList(a.OtherList), reset)
+			if len(a.Redirects) > 0 {
+				fmt.Println("跳转链:", httpgo.FormatRedirects(a.Redirects, a.FinalUrl))
+			}
+			if a.ErrorClass != "" {
+				fmt.Println("错误类型:", a.ErrorClass)
+			}
+			if a.Error != "" {
+				fmt.Println("错误原因:", a.Error)
+			}
+			if a.Protocol != "" {
+				if len(a.AltSvc) > 0 {
+					fmt.Printf("协议: %s (Alt-Svc: %s)\n", a.Protocol, strings.Join(a.AltSvc, ","))
+				} else {
+					fmt.Println("协议:", a.Protocol)
+				}
+			}
+			if a.TLS != nil {
+				fmt.Println("TLS:", a.TLS.Version, a.TLS.CipherSuite)
+			}
+			if a.JARM != "" {
+				fmt.Println("JARM:", a.JARM)
+			}
+			if leaf := a.TLS.Leaf(); leaf != nil {
+				fmt.Printf("证书: CN: %s / SAN: %s / 签发者: %s / 到期时间: %s\n", leaf.CommonName, strings.Join(leaf.SANs, ","), leaf.Issuer, leaf.NotAfter.Format("2006-01-02 15:04:05"))
+			}
+			if len(a.TLSIssues) > 0 {
+				fmt.Println("TLS问题:", httpgo.FormatTLSIssues(a.TLSIssues))
+			}
+			t := a.Timing
+			fmt.Printf("耗时: DNS %v / 连接 %v / TLS %v / 首字节 %v / 总计 %v\n", t.DNS.Round(time.Millisecond), t.Connect.Round(time.Millisecond), t.TLS.Round(time.Millisecond), t.TTFB.Round(time.Millisecond), t.Total.Round(time.Millisecond))
 		}
+		return
+	}
 
-		a, err := fingerprint.GetFinger(*urlFlag, *proxyFlag, fingerlist, *timeoutInt)
+	var ports []int
+	if *portsFlag != "" {
+		ports, err = utils.ParsePorts(*portsFlag)
 		if err != nil {
-			fmt.Println("Error getting fingerprint:", err)
+			fmt.Fprintln(info, "Error parsing ports:", err)
 			return
 		}
-		fmt.Printf("%-20s %-10s %-20s %-10s %-10s\n", "URL", "Status", "Title", "CMS List", "Other List")
-		fmt.Printf("%-20s %-10d %-20s %s%-10s%s %s%-10s%s\n", a.Url, a.StatusCode, a.Title, green, utils.FormatCmsList(a.CmsList), reset, red, utils.FormatCmsList(a.OtherList), reset)
-		return
 	}
 
-	targetlist, err := utils.ReadFileToSlice(*fileFlag)
+	formats, err := sink.ParseFormats(*outputFormats)
 	if err != nil {
-		//fmt.Println("Error reading file:", err)
-		//return
+		fmt.Fprintln(info, err)
+		return
 	}
 
-	// 创建一个通道来控制并发数量
-	sem := make(chan struct{}, *thead)
-
-	// 使用WaitGroup和goroutines并发处理URL
-	var wg sync.WaitGroup
+	// 未指定 -url 和 -file，或 -file - 时从标准输入读取目标
+	useStdin := *fileFlag == "-" || *fileFlag == ""
+	if *fileFlag == "" {
+		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			flag.Usage()
+			return
+		}
+	}
 
+	// 锁定输出目录，防止两个扫描同时写入同一目录
 	outdir := dir + "/" + *output
-	outdircsv := outdir + "/" + *output + ".csv"
-	outdirhtml := outdir + "/" + *output + ".html"
-	outdirjson := outdir + "/" + *output + ".json"
-	outhtmljson := *output + ".json"
-
-	// 创建目录（如果不存在）
-	err = os.MkdirAll(outdir, os.ModePerm)
-	if err != nil {
-		fmt.Println("创建目录出错:", err)
+	if err := os.MkdirAll(outdir, os.ModePerm); err != nil {
+		fmt.Fprintln(info, "创建目录出错:", err)
 		return
 	}
-
-	// 替换.html为.json
-	reportJson := outdirjson
-
-	// 创建HTML报告
-	htmlfile, err := utils.InitializeHTMLReport(outdirhtml, outhtmljson)
+	lock := flock.New(outdir + "/" + *output + ".lock")
+	locked, err := lock.TryLock()
 	if err != nil {
-		fmt.Println("Error creating HTML report:", err)
+		fmt.Fprintln(info, "锁定输出目录出错:", err)
 		return
 	}
-	defer htmlfile.Close()
-
-	// 创建CSV文件
-	file, err := os.Create(outdircsv)
-	if err != nil {
-		fmt.Println("创建CSV文件出错:", err)
+	if !locked {
+		fmt.Fprintf(info, "输出目录 %s 正在被另一个扫描使用\n", outdir)
 		return
 	}
-	defer file.Close()
+	defer lock.Unlock()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	// 使用本地浏览器截图，截图保存在输出目录中
+	var shots *screenshot.Pool
+	defer func() {
+		if shots != nil {
+			shots.Close()
+		}
+	}()
+	if !*noScreenshot {
+		shots, err = screenshot.New(screenshot.Options{
+			Browser:   *browserFlag,
+			OutputDir: outdir,
+			Tabs:      *screenshotTabs,
+			Timeout:   *screenshotTimeout,
+			Proxy:     *proxyFlag,
+		})
+		if err == screenshot.ErrNoBrowser {
+			fmt.Fprintln(info, "未找到 Chromium/Chrome，不进行截图，可使用 -browser 指定浏览器路径")
+		} else if err != nil {
+			fmt.Fprintln(info, "启动浏览器出错，不进行截图:", err)
+		} else {
+			scannerOpts.Screenshots = shots
+			if scanner, err = fingerprint.NewScanner(scannerOpts); err != nil {
+				fmt.Fprintln(info, "Error creating scanner:", err)
+				return
+			}
+		}
+	}
+
+	// 续扫时读取已完成的目标
+	checkpointPath := outdir + "/" + *output + ".checkpoint"
+	done := make(map[string]struct{})
+	if *resume {
+		done, err = sink.LoadCheckpoint(checkpointPath)
+		if err != nil {
+			fmt.Fprintln(info, "读取断点文件出错:", err)
+			return
+		}
+		fmt.Fprintf(info, "从断点继续扫描，已完成 %d 个目标\n", len(done))
+	}
 
-	// 写入CSV表头
-	header := []string{"Url", "StatusCode", "Title", "CmsList", "OtherList"}
-	if err := writer.Write(header); err != nil {
-		fmt.Println("写入CSV表头出错:", err)
+	// 创建输出文件，所有结果经同一个通道交给写入goroutine
+	var refresh time.Duration
+	if *server != "" {
+		// 开启web服务时定时刷新json文件，以便实时查看
+		refresh = 5 * time.Second
+	}
+	files, err := sink.OpenFiles(formats, sink.FileOptions{
+		Dir:     outdir,
+		Name:    *output,
+		Refresh: refresh,
+		Append:  *resume,
+	})
+	if err != nil {
+		fmt.Fprintln(info, "创建输出文件出错:", err)
 		return
 	}
-	if *fileFlag != "" {
-		fmt.Printf("%-40s %-10s %-30s %-10s %-10s\n", "URL", "Status", "Title", "CMSList", "OtherList")
+	checkpoint, err := sink.NewCheckpoint(checkpointPath, *resume)
+	if err != nil {
+		files.Close()
+		fmt.Fprintln(info, "创建断点文件出错:", err)
+		return
 	}
-
-	for _, target := range targetlist {
-		wg.Add(1)
-		sem <- struct{}{} // 向通道发送数据，阻塞直到通道有可用空间
-		go func(url string) {
-			defer wg.Done()
-			defer func() { <-sem }() // 从通道读取数据，以释放空间
-
-			a, err := fingerprint.GetFinger(url, *proxyFlag, fingerlist, *timeoutInt)
+	sinks := append(sink.Multi{sink.NewConsole(os.Stdout, *jsonOutput)}, files...)
+
+	// 第一次 Ctrl+C 取消未完成的请求并保存已完成的结果，第二次强制退出
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	scanDone := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+		case <-scanDone:
+			return
+		}
+		fmt.Fprintln(info, "\n正在停止扫描并保存已完成的结果，再次按 Ctrl+C 强制退出")
+		cancel()
+		select {
+		case <-sigs:
+			fmt.Fprintln(info, "强制退出")
+			os.Exit(1)
+		case <-scanDone:
+		}
+	}()
+
+	// 逐行读取并展开目标（CIDR、IP范围、端口组合），边展开边扫描
+	targets := make(chan string)
+	go func() {
+		defer close(targets)
+		var f io.Reader = os.Stdin
+		if !useStdin {
+			file, err := os.Open(*fileFlag)
 			if err != nil {
-				fmt.Println("获取指纹失败:", err)
+				fmt.Fprintln(info, "Error reading file:", err)
 				return
 			}
+			defer file.Close()
+			f = file
+		}
 
-			fmt.Printf("%-40s %-10d %-30s %s%-10s%s %s%-10s%s\n", a.Url, a.StatusCode, a.Title, green, utils.FormatCmsList(a.CmsList), reset, red, utils.FormatCmsList(a.OtherList), reset)
-
-			// 将 CmsList 转换为单个字符串
-			cmsListStr := strings.Join(a.CmsList, ";")
-			otherListStr := strings.Join(a.OtherList, ";")
-			// 将结果写入CSV文件
-			record := []string{url, strconv.Itoa(a.StatusCode), a.Title, cmsListStr, otherListStr}
-			if err := writer.Write(record); err != nil {
-				fmt.Println("写入CSV文件出错:", err)
+		send := func(target string) bool {
+			// 跳过断点文件中已完成的目标
+			if _, ok := done[target]; ok {
+				return true
 			}
-
-			reports := utils.URLFingerprint{
-				Url:        a.Url,
-				StatusCode: a.StatusCode,
-				Title:      a.Title,
-				CmsList:    cmsListStr,
-				OtherList:  otherListStr,
-				Screenshot: a.Screenshot,
+			select {
+			case targets <- target:
+				return true
+			case <-ctx.Done():
+				return false
 			}
-			// 保存.json文件
-			if err := utils.AppendJSONReport(reportJson, reports); err != nil {
-				fmt.Println("写入JSON报告出错:", err)
+		}
+		err := utils.ExpandTargets(f, ports, func(target string) bool {
+			// 不带协议的目标同时探测两种协议时，展开为 https 和 http 两个目标
+			if *bothSchemes {
+				for _, t := range httpgo.WithSchemes(target) {
+					if !send(t) {
+						return false
+					}
+				}
+				return true
+			}
+			return send(target)
+		})
+		if err != nil {
+			fmt.Fprintln(info, "Error reading file:", err)
+		}
+	}()
+
+	// 所有结果由当前goroutine写入输出
+	completed := 0
+	for r := range scanner.ScanStream(ctx, targets) {
+		if r.Err != nil {
+			// 被取消的目标结果不完整，不写入结果与断点，续扫时重新扫描
+			if ctx.Err() == nil {
+				fmt.Fprintln(info, "获取指纹失败:", r.Err)
 			}
+			continue
+		}
+		// 结果写入所有输出后才记录到断点文件，写入失败的目标续扫时重新扫描
+		if err := sinks.Write(r.Fingers); err != nil {
+			fmt.Fprintln(info, "写入结果出错:", err)
+		} else if err := checkpoint.Write(r.Fingers); err != nil {
+			fmt.Fprintln(info, "写入断点文件出错:", err)
+		}
+		completed++
+	}
 
-		}(target)
+	// 关闭输出文件
+	if err := sinks.Close(); err != nil {
+		fmt.Fprintln(info, "写入结果出错:", err)
+	}
+	if err := checkpoint.Close(); err != nil {
+		fmt.Fprintln(info, "写入断点文件出错:", err)
 	}
+	close(scanDone)
+	signal.Stop(sigs)
 
-	wg.Wait()
+	// 扫描结束后立即关闭浏览器
+	if shots != nil {
+		shots.Close()
+		shots = nil
+	}
 
 	// 记录结束时间并计算耗时
 	elapsed := time.Since(start)
-	fmt.Printf("处理完毕，共计耗时: %s\n", elapsed)
+	if ctx.Err() != nil {
+		fmt.Fprintf(info, "扫描已中断，已完成 %d 个目标，共计耗时: %s\n", completed, elapsed)
+		fmt.Fprintf(info, "结果已保存到 %s，使用 -resume 可继续扫描剩余目标\n", outdir)
+		return
+	}
+	fmt.Fprintf(info, "处理完毕，共完成 %d 个目标，共计耗时: %s\n", completed, elapsed)
+
+	// JSON输出模式用于管道，未开启web服务时直接退出
+	if *jsonOutput && *server == "" {
+		return
+	}
 
 	//捕获系统信号，保持程序运行，防止web服务关闭
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, os.Kill)
 
-	fmt.Println("指纹识别完成，按 Ctrl+C 停止，\n如开启了web服务，请不再浏览web结果时使用 Ctrl+C 关闭，否则无法正常访问结果展示页面。")
+	fmt.Fprintln(info, "指纹识别完成，按 Ctrl+C 停止，\n如开启了web服务，请不再浏览web结果时使用 Ctrl+C 关闭，否则无法正常访问结果展示页面。")
 	<-c // 等待信号
-	fmt.Println("程序已退出")
+	fmt.Fprintln(info, "程序已退出")
 }
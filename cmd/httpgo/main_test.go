@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// binary 测试前编译的 httpgo 可执行文件
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "httpgo-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "httpgo")
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "build httpgo: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// pageServer 记录每个页面被请求的次数
+type pageServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func newPageServer(t *testing.T) *pageServer {
+	s := &pageServer{hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		s.mu.Unlock()
+		fmt.Fprintf(w, "<html><title>page %s</title></html>", r.URL.Path)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pageServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+// run 在 dir 中运行 httpgo，stdin 作为标准输入，返回标准输出、标准错误和退出错误。
+// 默认使用仓库中的指纹文件且不截图，args 中的 -fingers 会覆盖默认值
+func run(t *testing.T, dir string, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+	fingers, err := filepath.Abs("fingers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(binary, append([]string{"-fingers", fingers, "-no-screenshot"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// runHTTPGo 运行 httpgo，退出码非0时测试失败
+func runHTTPGo(t *testing.T, dir string, stdin string, args ...string) (string, string) {
+	t.Helper()
+	stdout, stderr, err := run(t, dir, stdin, args...)
+	if err != nil {
+		t.Fatalf("httpgo %v: %v\nstdout:\n%s\nstderr:\n%s", args, err, stdout, stderr)
+	}
+	return stdout, stderr
+}
+
+// jsonURLs 解析 -json 输出的每一行，返回排序后的 Url
+func jsonURLs(t *testing.T, stdout string) []string {
+	t.Helper()
+	var urls []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		if line == "" {
+			continue
+		}
+		var r struct{ Url string }
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("stdout line is not JSON: %q", line)
+		}
+		urls = append(urls, r.Url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestJSONStdin(t *testing.T) {
+	srv := newPageServer(t)
+	// favicon 地址无法解析的页面
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><title>bad</title><link rel="icon" href="%zz/icon.png"></html>`)
+	}))
+	defer bad.Close()
+	// 已关闭的端口
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := "http://" + l.Addr().String()
+	l.Close()
+
+	targets := []string{srv.URL + "/a", srv.URL + "/b", bad.URL, closed}
+	stdout, _ := runHTTPGo(t, t.TempDir(), strings.Join(targets, "\n")+"\n", "-json", "-o", "jsonl")
+	// 标准输出每一行都是一条结果，提示与错误信息只输出到标准错误
+	sort.Strings(targets)
+	if got := jsonURLs(t, stdout); !slices.Equal(got, targets) {
+		t.Errorf("stdout results %v, want %v", got, targets)
+	}
+}
+
+func TestResume(t *testing.T) {
+	srv := newPageServer(t)
+	dir := t.TempDir()
+	targets := srv.URL + "/a\n" + srv.URL + "/b\n" + srv.URL + "/c\n"
+	os.WriteFile(filepath.Join(dir, "targets.txt"), []byte(targets), 0644)
+	outdir := filepath.Join(dir, "out")
+	checkpoint := filepath.Join(outdir, "out.checkpoint")
+
+	// 上次扫描完成了 /a，崩溃时 /b 只写了一半
+	os.MkdirAll(outdir, 0755)
+	os.WriteFile(checkpoint, []byte(srv.URL+"/a\n"+srv.URL+"/b"), 0644)
+	os.WriteFile(filepath.Join(outdir, "out.jsonl"), []byte(`{"Url":"`+srv.URL+`/a"}`+"\n"), 0644)
+
+	stdout, _ := runHTTPGo(t, dir, "", "-json", "-resume", "-file", "targets.txt", "-output", "out", "-o", "jsonl")
+	if got, want := jsonURLs(t, stdout), []string{srv.URL + "/b", srv.URL + "/c"}; !slices.Equal(got, want) {
+		t.Errorf("resumed scan output %v, want %v", got, want)
+	}
+	if srv.count("/a") != 0 || srv.count("/b") != 1 || srv.count("/c") != 1 {
+		t.Errorf("requests: /a %d, /b %d, /c %d", srv.count("/a"), srv.count("/b"), srv.count("/c"))
+	}
+
+	done, _ := os.ReadFile(checkpoint)
+	lines := strings.Split(strings.TrimSpace(string(done)), "\n")
+	sort.Strings(lines)
+	if want := []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/c"}; !slices.Equal(lines, want) {
+		t.Errorf("checkpoint %q, want %v", done, want)
+	}
+	results, _ := os.ReadFile(filepath.Join(outdir, "out.jsonl"))
+	if got, want := jsonURLs(t, string(results)), []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/c"}; !slices.Equal(got, want) {
+		t.Errorf("jsonl results %v, want %v", got, want)
+	}
+
+	// 全部完成后再次续扫不发送请求
+	stdout, _ = runHTTPGo(t, dir, "", "-json", "-resume", "-file", "targets.txt", "-output", "out", "-o", "jsonl")
+	if strings.TrimSpace(stdout) != "" || srv.count("/b") != 1 {
+		t.Errorf("second resume scanned again: %q", stdout)
+	}
+}
+
+func TestBadRules(t *testing.T) {
+	srv := newPageServer(t)
+	dir := t.TempDir()
+	rules := `[
+		{"name": "good", "keyword": "title=\"page\"", "type": "cms"},
+		{"name": "bad", "keyword": "title=\"a\" ||", "type": "cms"}
+	]`
+	os.WriteFile(filepath.Join(dir, "rules.json"), []byte(rules), 0644)
+
+	// 存在语法错误的规则时退出码非0，不开始扫描
+	stdout, stderr, err := run(t, dir, srv.URL+"/a\n", "-json", "-fingers", "rules.json", "-o", "jsonl")
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() == 0 {
+		t.Fatalf("got %v, want a non-zero exit code", err)
+	}
+	if stdout != "" || !strings.Contains(stderr, "bad") || srv.count("/a") != 0 {
+		t.Errorf("stdout %q, stderr %q, %d requests", stdout, stderr, srv.count("/a"))
+	}
+
+	// -skip-bad-rules 时使用其余规则继续扫描
+	stdout, _ = runHTTPGo(t, dir, srv.URL+"/a\n", "-json", "-fingers", "rules.json", "-o", "jsonl", "-skip-bad-rules")
+	var r struct{ CmsList string }
+	if err := json.Unmarshal([]byte(stdout), &r); err != nil || r.CmsList != "good" {
+		t.Errorf("got %q, want a result matching the good rule", stdout)
+	}
+}
@@ -1,337 +1,170 @@
 package fingerprint
 
 import (
+	"context"
 	"fmt"
 	"httpgo/pkg/httpgo"
 	"httpgo/pkg/utils"
+	"net"
 	"net/url"
-	"regexp"
 	"strings"
-	"time"
 )
 
 type Fingers struct {
+	Target     string // 输入的原始目标，如不带协议的 host:port
 	Url        string
 	StatusCode int
 	Title      string
 	CmsList    []string
 	OtherList  []string
-	Screenshot string
+	Screenshot string                       // 截图文件相对于输出目录的路径，未截图时为空
+	Extracted  map[string]map[string]string // 正则命名分组提取的内容，如版本号
+	FinalUrl   string                       // 跟随跳转后的最终地址
+	Redirects  []httpgo.RedirectHop         // 跳转链
+	ErrorClass string                       // 请求失败时的错误类型，如 dns、refused、timeout、tls、reset
+	Error      string                       // 请求失败的原因
+	Timing     httpgo.Timing                // 首页请求的各阶段耗时
+	TLS        *httpgo.TLSInfo              // TLS 连接与证书信息，明文HTTP时为 nil
+	JARM       string                       // TLS 服务的 JARM 指纹，未开启或非HTTPS时为空
+	TLSIssues  []httpgo.TLSIssue            // 证书与TLS配置问题，如证书过期、接受 TLS 1.0
+	Protocol   string                       // 使用的协议：http/1.0、http/1.1、h2
+	AltSvc     []string                     // Alt-Svc 通告的协议，包含 h3 时表示支持 HTTP/3
 }
 
-func GetFinger(urlStr string, proxyStr string, fingerlist []utils.FingerprintFile, timeoutInt time.Duration) (*Fingers, error) {
-	// 截图
-	ScreenShotPath := "https://s0.wp.com/mshots/v1/" + url.QueryEscape(urlStr)
+// GetFinger 请求目标并匹配指纹，client、rules 与 probes 在整个扫描中共享。
+// 目标不带协议时自动选择可用的协议，返回结果中的 Url 为实际请求的地址。
+// probes 缓存路径探测的响应，同一主机的每个探测请求只发送一次，为 nil 时每个目标都重新请求。
+// ctx 取消时未完成的请求立即结束，调用方应通过 ctx.Err() 判断结果是否完整。
+func GetFinger(ctx context.Context, urlStr string, client *httpgo.Client, rules *RuleSet, probes *ProbeCache) (*Fingers, error) {
+	target := urlStr
+	urlStr, a, err := client.DetectScheme(ctx, urlStr)
 
-	var cms []string
-	var other []string
-	a, err := httpgo.GetResponse(urlStr, proxyStr, timeoutInt)
 	if err != nil {
 		//fmt.Println("Error making HTTP request:", err)
 		return &Fingers{
+			Target:     target,
 			Url:        urlStr,
 			StatusCode: -1,
 			Title:      "",
 			CmsList:    nil,
 			OtherList:  nil,
-			Screenshot: ScreenShotPath,
+			ErrorClass: httpgo.ClassifyError(err),
+			Error:      err.Error(),
 		}, nil
 	}
 
+	// 请求失败的目标不再请求favicon和探测路径
+	if a.StatusCode == -1 {
+		return &Fingers{
+			Target:     target,
+			Url:        urlStr,
+			StatusCode: -1,
+			FinalUrl:   a.FinalUrl,
+			Redirects:  a.Redirects,
+			ErrorClass: a.ErrorClass,
+			Error:      a.Error,
+			Timing:     a.Timing,
+		}, nil
+	}
+
+	// TLS 检查与 JARM 指纹针对跳转后实际提供服务的地址
+	tlsIssues := client.TLSIssues(ctx, a.FinalUrl, a.TLS)
+	var jarm string
+	if client.JARMEnabled() && a.TLS != nil {
+		if addr := jarmAddr(a.FinalUrl); addr != "" {
+			jarm, _ = client.JARM(ctx, addr)
+		}
+	}
+
 	// 获取faviconhash
-	faviconhash, err := a.GetFaviconHash(proxyStr, timeoutInt)
+	faviconhash, err := a.GetFaviconHash(ctx, client)
 	if err != nil {
 		//fmt.Println("Error getting favicon hash:", err)
 		return &Fingers{
+			Target:     target,
 			Url:        urlStr,
 			StatusCode: a.StatusCode,
 			Title:      a.Title,
 			CmsList:    nil,
 			OtherList:  nil,
-			Screenshot: ScreenShotPath,
+			FinalUrl:   a.FinalUrl,
+			Redirects:  a.Redirects,
+			Timing:     a.Timing,
+			TLS:        a.TLS,
+			JARM:       jarm,
+			TLSIssues:  tlsIssues,
+			Protocol:   a.Protocol,
+			AltSvc:     a.AltSvc,
 		}, nil
 	}
 
-	for _, fp := range fingerlist {
-		if CheckFingerprint(a, fp.Keyword, faviconhash) {
-			//fmt.Printf("Matched fingerprint: %s\n", fp.Name)
-			if fp.Type == "cms" {
-				cms = append(cms, fp.Name)
-			} else {
-				other = append(other, fp.Name)
-			}
+	in := NewInput(a, faviconhash)
+	in.JARM = jarm
+	cmslist, otherlist, extracted := rules.Match(in)
+
+	// 路径探测：每个主机的每个去重后的探测请求只发送一次，只用于匹配依赖该请求的规则
+	for _, probe := range rules.Probes() {
+		if ctx.Err() != nil {
+			break
+		}
+		pr := probes.Get(ctx, client, probe, urlStr)
+		if pr == nil {
+			continue
 		}
+		pin := NewInput(pr, faviconhash)
+		pin.JARM = jarm
+		cms, other, ext := rules.MatchProbe(probe, pin)
+		cmslist = append(cmslist, cms...)
+		otherlist = append(otherlist, other...)
+		extracted = MergeExtracted(extracted, ext)
 	}
-
-	cmslist := httpgo.RemoveDuplicates(cms)
-	otherlist := httpgo.RemoveDuplicates(other)
-
-	// 请求一次Screenshot，方便后期快速查看
-	_, _ = httpgo.GetResponse(ScreenShotPath, proxyStr, timeoutInt)
+	cmslist = httpgo.RemoveDuplicates(cmslist)
+	otherlist = httpgo.RemoveDuplicates(otherlist)
 
 	return &Fingers{
+		Target:     target,
 		Url:        urlStr,
 		StatusCode: a.StatusCode,
 		Title:      utils.RemoveNewline(a.Title),
 		CmsList:    cmslist,
 		OtherList:  otherlist,
-		Screenshot: ScreenShotPath,
+		Extracted:  extracted,
+		FinalUrl:   a.FinalUrl,
+		Redirects:  a.Redirects,
+		Timing:     a.Timing,
+		TLS:        a.TLS,
+		JARM:       jarm,
+		TLSIssues:  tlsIssues,
+		Protocol:   a.Protocol,
+		AltSvc:     a.AltSvc,
 	}, nil
 }
 
-// unescape 去除转义字符
-func unescape(s string) string {
-	re := regexp.MustCompile(`\\(.)`)
-	return re.ReplaceAllString(s, "$1")
-}
-
-// evaluateCondition 检查单个条件是否匹配
-func evaluateCondition(condition, respBody, respHeader, respTitle string, respCert string, iconHashes []string) bool {
-	condition = strings.TrimSpace(condition)
-
-	switch {
-	case strings.HasPrefix(condition, "body="):
-		value := strings.Trim(strings.TrimPrefix(condition, "body="), "\"")
-		value = unescape(value)
-		return strings.Contains(respBody, value)
-	case strings.HasPrefix(condition, "header="):
-		value := strings.Trim(strings.TrimPrefix(condition, "header="), "\"")
-		value = unescape(value)
-		return strings.Contains(respHeader, value)
-	case strings.HasPrefix(condition, "title="):
-		value := strings.Trim(strings.TrimPrefix(condition, "title="), "\"")
-		value = unescape(value)
-		return strings.Contains(respTitle, value)
-	case strings.HasPrefix(condition, "cert="):
-		value := strings.Trim(strings.TrimPrefix(condition, "cert="), "\"")
-		value = unescape(value)
-		return strings.Contains(respCert, value)
-	case strings.HasPrefix(condition, "icon_hash="):
-		value := strings.Trim(strings.TrimPrefix(condition, "icon_hash="), "\"")
-		value = unescape(value)
-		for _, hash := range iconHashes {
-			if hash == value {
-				return true
-			}
-		}
-	case strings.HasPrefix(condition, "body!="):
-		value := strings.Trim(strings.TrimPrefix(condition, "body!="), "\"")
-		value = unescape(value)
-		return !strings.Contains(respBody, value)
-	case strings.HasPrefix(condition, "header!="):
-		value := strings.Trim(strings.TrimPrefix(condition, "header!="), "\"")
-		value = unescape(value)
-		return !strings.Contains(respHeader, value)
-	case strings.HasPrefix(condition, "title!="):
-		value := strings.Trim(strings.TrimPrefix(condition, "title!="), "\"")
-		value = unescape(value)
-		return !strings.Contains(respTitle, value)
-	case strings.HasPrefix(respCert, "cert!="):
-		value := strings.Trim(strings.TrimPrefix(condition, "cert!="), "\"")
-		value = unescape(value)
-		return !strings.Contains(respCert, value)
+// jarmAddr 返回 https 地址的 host:port，未指定端口时使用443
+func jarmAddr(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ""
 	}
-	return false
-}
-
-// tokenize 函数处理表达式，将其分割成token
-func tokenize(expression string) []string {
-	var tokens []string
-	var token strings.Builder
-	inQuotes := false
-	escaped := false
-	parens := 0
-
-	for i := 0; i < len(expression); i++ {
-		ch := expression[i]
-
-		if escaped {
-			token.WriteByte(ch)
-			escaped = false
-			continue
-		}
-
-		if ch == '\\' {
-			if i+1 < len(expression) && expression[i+1] == '"' {
-				escaped = true
-			} else {
-				token.WriteByte(ch)
-			}
-			continue
-		}
-
-		if ch == '"' {
-			if !escaped {
-				inQuotes = !inQuotes
-			}
-			token.WriteByte(ch)
-			continue
-		}
-
-		if ch == '(' {
-			if inQuotes {
-				token.WriteByte(ch)
-			} else {
-				if token.Len() > 0 {
-					tokens = append(tokens, token.String())
-					token.Reset()
-				}
-				tokens = append(tokens, string(ch))
-				parens++
-			}
-		} else if ch == ')' {
-			if inQuotes {
-				token.WriteByte(ch)
-			} else {
-				if token.Len() > 0 {
-					tokens = append(tokens, token.String())
-					token.Reset()
-				}
-				tokens = append(tokens, string(ch))
-				parens--
-			}
-		} else if ch == ' ' && !inQuotes {
-			if token.Len() > 0 {
-				tokens = append(tokens, token.String())
-				token.Reset()
-			}
-		} else if ch == '&' && i+1 < len(expression) && expression[i+1] == '&' {
-			if token.Len() > 0 {
-				tokens = append(tokens, token.String())
-				token.Reset()
-			}
-			tokens = append(tokens, "&&")
-			i++
-		} else if ch == '|' && i+1 < len(expression) && expression[i+1] == '|' {
-			if token.Len() > 0 {
-				tokens = append(tokens, token.String())
-				token.Reset()
-			}
-			tokens = append(tokens, "||")
-			i++
-		} else {
-			token.WriteByte(ch)
-		}
+	port := u.Port()
+	if port == "" {
+		port = "443"
 	}
-
-	if token.Len() > 0 {
-		tokens = append(tokens, token.String())
-	}
-
-	if parens != 0 {
-		fmt.Println("Error: Mismatched parentheses")
-	}
-
-	return tokens
+	return net.JoinHostPort(u.Hostname(), port)
 }
 
-// Shunting Yard 算法实现
-func shuntingYard(expression string) ([]string, error) {
-	var output []string
-	var operators []string
-
-	precedence := map[string]int{
-		"||": 1,
-		"&&": 2,
-	}
-	//fmt.Println("Expression:", expression)
-
-	tokens := tokenize(expression)
-
-	//fmt.Println("Tokens:", tokens) // 调试输出 tokens
-
-	for _, token := range tokens {
-		//fmt.Println("Token:", token)
-		switch token {
-		case "&&", "||":
-			for len(operators) > 0 {
-				top := operators[len(operators)-1]
-				if top == "(" || precedence[token] > precedence[top] {
-					break
-				}
-				output = append(output, top)
-				operators = operators[:len(operators)-1]
-			}
-			operators = append(operators, token)
-		case "(":
-			operators = append(operators, token)
-		case ")":
-			for len(operators) > 0 && operators[len(operators)-1] != "(" {
-				output = append(output, operators[len(operators)-1])
-				operators = operators[:len(operators)-1]
-			}
-			if len(operators) == 0 {
-				return nil, fmt.Errorf("mismatched parentheses")
-			}
-			operators = operators[:len(operators)-1]
-		default:
-			output = append(output, token)
-		}
-	}
-
-	for len(operators) > 0 {
-		output = append(output, operators[len(operators)-1])
-		operators = operators[:len(operators)-1]
-	}
-
-	return output, nil
-}
-
-// CheckFingerprint 检查响应内容是否匹配指纹规则
-func CheckFingerprint(response *httpgo.Response, expression string, faviconhashs *httpgo.FaviconList) bool {
-	//expression 为fp.Keyword
-	postfix, err := shuntingYard(expression)
+// CheckFingerprint 检查响应内容是否匹配单条指纹规则。
+// 每次调用都会重新解析 expression，批量匹配时应使用 Compile 得到的 RuleSet。
+func CheckFingerprint(response *httpgo.Response, expression string, faviconhashs *httpgo.FaviconList) (bool, error) {
+	expr, err := parseExpression(expression)
 	if err != nil {
-		fmt.Println("Error parsing expression:", err)
-		return false
-	}
-	return evaluatePostfix(postfix, string(response.Body), response.HeadersStr, response.Title, response.Cert, faviconhashs.FaviconHash)
-}
-
-// evaluatePostfix 评估后缀表达式
-func evaluatePostfix(postfix []string, respBody, respHeader, respTitle string, respCert string, iconHashes []string) bool {
-	var stack []bool
-	//检查指纹错误
-	//fmt.Printf("Evaluating postfix expression: %v\n", postfix) // 打印后缀表达式
-
-	for _, token := range postfix {
-		switch token {
-		case "&&":
-			if len(stack) < 2 {
-				fmt.Println("Error: Insufficient values for AND operation")
-				return false
-			}
-			v1 := stack[len(stack)-2]
-			v2 := stack[len(stack)-1]
-			stack = stack[:len(stack)-2]
-			stack = append(stack, v1 && v2)
-		case "||":
-			if len(stack) < 2 {
-				fmt.Println("Error: Insufficient values for OR operation")
-				return false
-			}
-			v1 := stack[len(stack)-2]
-			v2 := stack[len(stack)-1]
-			stack = stack[:len(stack)-2]
-			stack = append(stack, v1 || v2)
-		default:
-			stack = append(stack, evaluateCondition(token, respBody, respHeader, respTitle, respCert, iconHashes))
-		}
-	}
-
-	if len(stack) != 1 {
-		fmt.Println("Error: Invalid expression")
-		return false
+		return false, &ParseError{Index: -1, Keyword: expression, Column: err.column, Msg: err.msg}
 	}
-	return stack[0]
+	return expr.Match(NewInput(response, faviconhashs)), nil
 }
 
+// ValidateFingerprints 检查指纹规则的语法，返回第一个出错的规则
 func ValidateFingerprints(fingerlist []utils.FingerprintFile) error {
-	validPrefixes := []string{
-		"body=", "header=", "title=", "cert=", "icon_hash=",
-		"body!=", "header!=", "title!=", "cert!=",
-	}
-
 	for i, fp := range fingerlist {
 		if fp.Keyword == "" {
 			return fmt.Errorf("fingerprint %d ('%s') has an empty keyword", i, fp.Name)
@@ -340,37 +173,11 @@ func ValidateFingerprints(fingerlist []utils.FingerprintFile) error {
 		// Attempt to fix common issues in the keyword
 		fp.Keyword = fixCommonIssues(fp.Keyword)
 
-		// Tokenize and parse the keyword to check syntax
-		tokens := tokenize(fp.Keyword)
-		if tokens == nil {
-			return fmt.Errorf("fingerprint %d ('%s') has invalid syntax in keyword: '%s'", i, fp.Name, fp.Keyword)
+		if _, err := parseExpression(fp.Keyword); err != nil {
+			return &ParseError{Index: i, Name: fp.Name, Keyword: fp.Keyword, Column: err.column, Msg: err.msg}
 		}
-
-		// Validate each token in the expression
-		for _, token := range tokens {
-			// Skip logical operators and parentheses
-			if token == "&&" || token == "||" || token == "(" || token == ")" {
-				continue
-			}
-
-			// Ensure the token starts with a valid prefix
-			isValid := false
-			for _, prefix := range validPrefixes {
-				if strings.HasPrefix(token, prefix) {
-					isValid = true
-					break
-				}
-			}
-
-			if !isValid {
-				return fmt.Errorf("fingerprint %d ('%s') has an invalid token in keyword: '%s'", i, fp.Name, token)
-			}
-		}
-
-		// Check overall syntax using Shunting Yard
-		_, err := shuntingYard(fp.Keyword)
-		if err != nil {
-			return fmt.Errorf("fingerprint %d ('%s') has invalid logical syntax: '%s'", i, fp.Name, fp.Keyword)
+		if _, err := newProbe(fp); err != nil {
+			return &ParseError{Index: i, Name: fp.Name, Keyword: fp.Keyword, Msg: err.Error()}
 		}
 	}
 	return nil
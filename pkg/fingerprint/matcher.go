@@ -0,0 +1,274 @@
+package fingerprint
+
+import (
+	"sort"
+	"sync"
+)
+
+// automaton Aho-Corasick 多模式匹配自动机，构建后只读，可并发使用
+type automaton struct {
+	root  [256]int32 // 根节点的稠密转移表
+	nodes []acNode
+}
+
+type acNode struct {
+	keys []byte  // 子节点转移字符（有序）
+	next []int32 // 与 keys 一一对应的子节点
+	fail int32   // 失败指针
+	dict int32   // 沿失败链最近的有输出的节点，-1 表示没有
+	out  []int   // 在此节点结束的模式串编号
+}
+
+func newAutomaton() *automaton {
+	a := &automaton{nodes: []acNode{{fail: 0, dict: -1}}}
+	return a
+}
+
+// child 查找子节点，不存在返回 -1
+func (a *automaton) child(state int32, ch byte) int32 {
+	if state == 0 {
+		if n := a.root[ch]; n != 0 {
+			return n
+		}
+		return -1
+	}
+	n := &a.nodes[state]
+	lo, hi := 0, len(n.keys)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if n.keys[mid] < ch {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(n.keys) && n.keys[lo] == ch {
+		return n.next[lo]
+	}
+	return -1
+}
+
+// add 添加模式串
+func (a *automaton) add(pattern string, id int) {
+	var state int32
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		next := a.child(state, ch)
+		if next < 0 {
+			next = int32(len(a.nodes))
+			a.nodes = append(a.nodes, acNode{dict: -1})
+			if state == 0 {
+				a.root[ch] = next
+			} else {
+				n := &a.nodes[state]
+				j := sort.Search(len(n.keys), func(j int) bool { return n.keys[j] >= ch })
+				n.keys = append(n.keys, 0)
+				n.next = append(n.next, 0)
+				copy(n.keys[j+1:], n.keys[j:])
+				copy(n.next[j+1:], n.next[j:])
+				n.keys[j] = ch
+				n.next[j] = next
+			}
+		}
+		state = next
+	}
+	a.nodes[state].out = append(a.nodes[state].out, id)
+}
+
+// build 按广度优先计算失败指针和输出链接
+func (a *automaton) build() {
+	var queue []int32
+	for ch := 0; ch < 256; ch++ {
+		if n := a.root[ch]; n != 0 {
+			a.nodes[n].fail = 0
+			queue = append(queue, n)
+		}
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		n := &a.nodes[state]
+		for i, ch := range n.keys {
+			next := n.next[i]
+			f := n.fail
+			for {
+				if c := a.child(f, ch); c >= 0 {
+					a.nodes[next].fail = c
+					break
+				}
+				if f == 0 {
+					a.nodes[next].fail = 0
+					break
+				}
+				f = a.nodes[f].fail
+			}
+			fail := a.nodes[next].fail
+			if len(a.nodes[fail].out) > 0 {
+				a.nodes[next].dict = fail
+			} else {
+				a.nodes[next].dict = a.nodes[fail].dict
+			}
+			queue = append(queue, next)
+		}
+	}
+}
+
+// scan 扫描一遍文本，对每个出现过的模式串调用 found（每个模式串最多一次）。
+// visited 长度不小于节点数且全为 false，由调用方复用
+func (a *automaton) scan(text string, visited []bool, found func(id int)) {
+	var state int32
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		for {
+			if next := a.child(state, ch); next >= 0 {
+				state = next
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = a.nodes[state].fail
+		}
+
+		// 沿输出链报告，已访问过的节点其后续链也已报告过
+		for s := state; s > 0; s = a.nodes[s].dict {
+			if visited[s] {
+				break
+			}
+			visited[s] = true
+			for _, id := range a.nodes[s].out {
+				found(id)
+			}
+		}
+	}
+}
+
+// literal 规则中出现的字面量，fold 为true时 value 为小写，在小写文本中查找
+type literal struct {
+	field Field
+	fold  bool
+	value string
+}
+
+// scanKey 每个字段（区分是否忽略大小写）对应一个自动机
+type scanKey struct {
+	field Field
+	fold  bool
+}
+
+// prefilter 对规则中的 body/header/title/cert 字面量按字段各建一个自动机。
+// 每个响应的每个字段只扫描一遍，只有触发字面量出现过的规则才会进行完整求值。
+type prefilter struct {
+	automata map[scanKey]*automaton
+	triggers [][]int // 字面量编号 -> 以其为触发条件的规则下标
+	always   []int   // 无法预过滤、每次都需要求值的规则下标
+	maxNodes int     // 各自动机中最多的节点数
+	visited  sync.Pool
+}
+
+// requiredLiterals 计算使表达式可能为真的触发字面量集合：
+// 若集合中的字面量全部未出现，则表达式必然为假。返回 nil 表示无法预过滤。
+func requiredLiterals(n Node) []literal {
+	switch x := n.(type) {
+	case *Cond:
+		// == 完全相等时必然也包含该字面量
+		if (x.Op == OpContains || x.Op == OpEquals) && x.Value != "" && x.Field != FieldIconHash && !x.Field.numeric() {
+			if x.Fold {
+				return []literal{{x.Field, true, x.lower}}
+			}
+			return []literal{{x.Field, false, x.Value}}
+		}
+	case *And:
+		l, r := requiredLiterals(x.Left), requiredLiterals(x.Right)
+		// 两边都必须为真，任选一边的触发集合即可，取较小的一边
+		if l == nil || (r != nil && len(r) < len(l)) {
+			return r
+		}
+		return l
+	case *Or:
+		l, r := requiredLiterals(x.Left), requiredLiterals(x.Right)
+		if l == nil || r == nil {
+			return nil
+		}
+		return append(append([]literal{}, l...), r...)
+	}
+	return nil
+}
+
+// newPrefilter 根据编译后的规则构建预过滤器
+func newPrefilter(rules []*Rule) *prefilter {
+	p := &prefilter{automata: make(map[scanKey]*automaton)}
+	ids := make(map[literal]int)
+
+	for i, r := range rules {
+		// 需要路径探测的规则由 MatchProbe 单独求值
+		if r.Probe != nil {
+			continue
+		}
+		lits := requiredLiterals(r.Expr)
+		if lits == nil {
+			p.always = append(p.always, i)
+			continue
+		}
+		for _, lit := range lits {
+			id, ok := ids[lit]
+			if !ok {
+				id = len(p.triggers)
+				ids[lit] = id
+				p.triggers = append(p.triggers, nil)
+				key := scanKey{lit.field, lit.fold}
+				a, ok := p.automata[key]
+				if !ok {
+					a = newAutomaton()
+					p.automata[key] = a
+				}
+				a.add(lit.value, id)
+			}
+			p.triggers[id] = append(p.triggers[id], i)
+		}
+	}
+
+	for _, a := range p.automata {
+		a.build()
+		if len(a.nodes) > p.maxNodes {
+			p.maxNodes = len(a.nodes)
+		}
+	}
+	p.visited.New = func() interface{} {
+		v := make([]bool, p.maxNodes)
+		return &v
+	}
+	return p
+}
+
+// candidates 返回可能匹配的规则下标（升序）
+func (p *prefilter) candidates(in *Input, n int) []int {
+	mark := make([]bool, n)
+	for _, i := range p.always {
+		mark[i] = true
+	}
+	buf := p.visited.Get().(*[]bool)
+	defer p.visited.Put(buf)
+	for key, a := range p.automata {
+		text := in.text(key.field)
+		if key.fold {
+			text = in.lowerText(key.field)
+		}
+		visited := (*buf)[:len(a.nodes)]
+		clear(visited)
+		a.scan(text, visited, func(id int) {
+			for _, i := range p.triggers[id] {
+				mark[i] = true
+			}
+		})
+	}
+
+	var result []int
+	for i, ok := range mark {
+		if ok {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,205 @@
+package fingerprint
+
+import (
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/utils"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// benchFingers 仓库自带的指纹文件
+const benchFingers = "../../cmd/httpgo/fingers.json"
+
+// benchResponse 构造一个约 64KB 的模拟响应，夹带少量真实指纹中的字面量
+func benchResponse() *httpgo.Response {
+	r := rand.New(rand.NewSource(1))
+	words := []string{"div", "class=\"row\"", "<script src=\"/static/js/app.js\"></script>", "container", "<a href=\"/login\">", "span", "input", "登录", "用户名", "密码", "jquery.min.js", "</div>", "\n"}
+
+	var sb strings.Builder
+	sb.WriteString("<html><head><title>系统登录</title></head><body>")
+	for sb.Len() < 64*1024 {
+		sb.WriteString(words[r.Intn(len(words))])
+		sb.WriteByte(' ')
+	}
+	sb.WriteString("nifi/images/ 华舜后台管理框架 Powered by Discuz!</body></html>")
+
+	return &httpgo.Response{
+		Url:        "http://127.0.0.1/",
+		StatusCode: 200,
+		Title:      "系统登录",
+		Body:       []byte(sb.String()),
+		HeadersStr: "Server: nginx\nContent-Type: text/html; charset=utf-8\nSet-Cookie: JSESSIONID=1234\n",
+	}
+}
+
+func loadBenchRules(tb testing.TB) ([]utils.FingerprintFile, *RuleSet) {
+	fingerlist, err := utils.LoadFingerprints(benchFingers)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	rs, err := Compile(fingerlist)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return fingerlist, rs
+}
+
+// conds 按顺序收集表达式中的全部条件
+func conds(n Node) []*Cond {
+	switch x := n.(type) {
+	case *Cond:
+		return []*Cond{x}
+	case *And:
+		return append(conds(x.Left), conds(x.Right)...)
+	case *Or:
+		return append(conds(x.Left), conds(x.Right)...)
+	case *Not:
+		return conds(x.X)
+	}
+	return nil
+}
+
+// literalInput 将每 step 条规则中的字面量填入对应字段，使大量规则成为候选，
+// upper 为true时转为大写，用于检查忽略大小写的匹配
+func literalInput(rules []*Rule, step int, upper bool) *Input {
+	var body, header, title, cert strings.Builder
+	var hashes []string
+	for i := 0; i < len(rules); i += step {
+		for _, c := range conds(rules[i].Expr) {
+			if c.Op == OpRegex {
+				continue
+			}
+			v := c.Value
+			if upper {
+				v = strings.ToUpper(v)
+			}
+			switch c.Field {
+			case FieldBody:
+				body.WriteString(v + " ")
+			case FieldHeader:
+				header.WriteString(v + "\n")
+			case FieldTitle:
+				title.WriteString(v + " ")
+			case FieldCert:
+				cert.WriteString(v + "\n")
+			case FieldIconHash:
+				hashes = append(hashes, v)
+			}
+		}
+	}
+	return &Input{
+		Body:       body.String(),
+		Header:     header.String(),
+		Title:      title.String(),
+		Cert:       cert.String(),
+		IconHashes: hashes,
+		StatusCode: 200,
+		BodyLen:    body.Len(),
+	}
+}
+
+// checkPrefilter 预过滤的结果必须与逐条求值完全一致
+func checkPrefilter(t *testing.T, name string, rs *RuleSet, in *Input) (cms []string, other []string) {
+	t.Helper()
+	plain := &RuleSet{Rules: rs.Rules}
+	wantCms, wantOther, wantExtracted := plain.Match(in)
+	gotCms, gotOther, gotExtracted := rs.Match(in)
+	if !reflect.DeepEqual(wantCms, gotCms) || !reflect.DeepEqual(wantOther, gotOther) || !reflect.DeepEqual(wantExtracted, gotExtracted) {
+		t.Errorf("%s: prefilter mismatch: got %v %v %v, want %v %v %v", name, gotCms, gotOther, gotExtracted, wantCms, wantOther, wantExtracted)
+	}
+	return gotCms, gotOther
+}
+
+func TestPrefilterMatchesFullEvaluation(t *testing.T) {
+	_, rs := loadBenchRules(t)
+	inputs := map[string]*Input{
+		"bench":         NewInput(benchResponse(), &httpgo.FaviconList{FaviconHash: []string{"-1"}}),
+		"empty":         {},
+		"literals":      literalInput(rs.Rules, 1, false),
+		"literals/7":    literalInput(rs.Rules, 7, false),
+		"literals/7 uc": literalInput(rs.Rules, 7, true),
+	}
+	for name, in := range inputs {
+		cms, other := checkPrefilter(t, name, rs, in)
+		if name == "literals" && len(cms)+len(other) == 0 {
+			t.Errorf("%s: no rule matched", name)
+		}
+	}
+
+	// 手工构造的规则，覆盖 !、||、忽略大小写和 ==
+	custom := []utils.FingerprintFile{
+		{Name: "not", Type: "cms", Keyword: `!body="nginx"`},
+		{Name: "and-not", Type: "cms", Keyword: `body="admin" && !title="test"`},
+		{Name: "not-group", Type: "cms", Keyword: `!(body="a" && header="b")`},
+		{Name: "or", Type: "cms", Keyword: `body="foo" || title="bar"`},
+		{Name: "or-not", Type: "cms", Keyword: `body="foo" || !header="x-powered-by"`},
+		{Name: "fold", Type: "cms", Keyword: `title*="login"`},
+		{Name: "fold-suffix", Type: "cms", Keyword: `body="WordPress"i`},
+		{Name: "fold-or", Type: "cms", Keyword: `body="JQUERY"i || header*="tomcat"`},
+		{Name: "equals", Type: "cms", Keyword: `title=="Login"`},
+		{Name: "equals-header", Type: "cms", Keyword: `header=="Server: nginx"`},
+		{Name: "equals-fold", Type: "cms", Keyword: `title=="LOGIN"i && body="form"`},
+		{Name: "not-equals", Type: "cms", Keyword: `body="form" && title!=="Login"`},
+		{Name: "regex", Type: "other", Keyword: `body~="v(?P<version>[\d.]+)" && body="jQuery"`},
+	}
+	crs, err := Compile(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		in   *Input
+		want []string
+	}{
+		{"empty", &Input{}, []string{"not", "not-group", "or-not"}},
+		{"login", &Input{Title: "Login", Body: "<form>", Header: "Server: nginx\n"}, []string{"not", "not-group", "or-not", "fold", "equals", "equals-header", "equals-fold"}},
+		{"login title case", &Input{Title: "LOGIN page", Body: "<form> admin", Header: "Server: Apache-Coyote/1.1 (Tomcat)\n"}, []string{"not", "and-not", "not-group", "or-not", "fold", "fold-or", "not-equals"}},
+		{"nginx", &Input{Title: "bar", Body: "nginx a wordpress", Header: "b\nX-Powered-By: PHP\n"}, []string{"or", "or-not", "fold-suffix"}},
+		{"jquery", &Input{Title: "test", Body: "foo admin jQuery v3.6.0", Header: "x-powered-by"}, []string{"not", "or", "or-not", "fold-or"}},
+	}
+	for _, tt := range tests {
+		cms, _ := checkPrefilter(t, tt.name, crs, tt.in)
+		if !reflect.DeepEqual(cms, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, cms, tt.want)
+		}
+	}
+}
+
+// BenchmarkParseEachMatch 每次匹配都重新解析规则再求值，用于对比规则只编译一次的收益
+func BenchmarkParseEachMatch(b *testing.B) {
+	fingerlist, _ := loadBenchRules(b)
+	resp := benchResponse()
+	favicons := &httpgo.FaviconList{FaviconHash: []string{"-1"}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, fp := range fingerlist {
+			_, _ = CheckFingerprint(resp, fp.Keyword, favicons)
+		}
+	}
+}
+
+// BenchmarkRuleSetNoPrefilter 使用编译后的表达式树，但对每条规则逐一求值
+func BenchmarkRuleSetNoPrefilter(b *testing.B) {
+	_, rs := loadBenchRules(b)
+	plain := &RuleSet{Rules: rs.Rules}
+	in := NewInput(benchResponse(), &httpgo.FaviconList{FaviconHash: []string{"-1"}})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		plain.Match(in)
+	}
+}
+
+// BenchmarkRuleSetPrefilter 先用 Aho-Corasick 扫描一遍各字段，再只对候选规则求值
+func BenchmarkRuleSetPrefilter(b *testing.B) {
+	_, rs := loadBenchRules(b)
+	in := NewInput(benchResponse(), &httpgo.FaviconList{FaviconHash: []string{"-1"}})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs.Match(in)
+	}
+}
@@ -0,0 +1,383 @@
+package fingerprint
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// syntaxError 关键字解析错误，column 从1开始
+type syntaxError struct {
+	column int
+	msg    string
+}
+
+func (e *syntaxError) Error() string {
+	return e.msg
+}
+
+type tokenKind int
+
+const (
+	tokCond tokenKind = iota
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	pos  int // 在表达式中的起始列（从1开始）
+	node Node
+}
+
+// precedence 逻辑运算符优先级，! 为前缀一元运算符，优先级最高
+var precedence = map[tokenKind]int{
+	tokOr:  1,
+	tokAnd: 2,
+	tokNot: 3,
+}
+
+// opSpec 运算符对应的比较方式
+type opSpec struct {
+	text   string
+	op     Operator
+	negate bool // 编译为 Not{Cond}
+	fold   bool // 忽略大小写
+}
+
+// operators 字段后支持的运算符，长的写在前面以便优先匹配
+var operators = []opSpec{
+	{"!~=", OpRegex, true, false},
+	{"!==", OpEquals, true, false},
+	{"!*=", OpContains, true, true},
+	{"!=", OpContains, true, false},
+	{"==", OpEquals, false, false},
+	{"~=", OpRegex, false, false},
+	{"*=", OpContains, false, true},
+	{">=", OpGreaterEqual, false, false},
+	{"<=", OpLessEqual, false, false},
+	{">", OpGreater, false, false},
+	{"<", OpLess, false, false},
+	{"=", OpContains, false, false},
+}
+
+// tokenize 将表达式分割成token，条件在此阶段直接解析为 Cond 节点
+func tokenize(expression string) ([]token, *syntaxError) {
+	var tokens []token
+
+	for i := 0; i < len(expression); {
+		ch := expression[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokLParen, pos: i + 1})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokRParen, pos: i + 1})
+			i++
+		case strings.HasPrefix(expression[i:], "&&"):
+			tokens = append(tokens, token{kind: tokAnd, pos: i + 1})
+			i += 2
+		case strings.HasPrefix(expression[i:], "||"):
+			tokens = append(tokens, token{kind: tokOr, pos: i + 1})
+			i += 2
+		case ch == '!':
+			tokens = append(tokens, token{kind: tokNot, pos: i + 1})
+			i++
+		default:
+			node, next, err := parseCondition(expression, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokCond, pos: i + 1, node: node})
+			i = next
+		}
+	}
+
+	return tokens, nil
+}
+
+// parseCondition 从 start 处解析一个 field<op>value 条件，返回条件节点和结束位置
+func parseCondition(expression string, start int) (Node, int, *syntaxError) {
+	i := start
+	for i < len(expression) && isFieldChar(expression[i]) {
+		i++
+	}
+	name := expression[start:i]
+	if name == "" {
+		return nil, 0, &syntaxError{start + 1, "unexpected character '" + string(expression[start]) + "'"}
+	}
+	field, ok := fields[name]
+	if !ok {
+		return nil, 0, &syntaxError{start + 1, "unknown field '" + name + "'"}
+	}
+
+	var spec *opSpec
+	for k := range operators {
+		if strings.HasPrefix(expression[i:], operators[k].text) {
+			spec = &operators[k]
+			break
+		}
+	}
+	if spec == nil {
+		return nil, 0, &syntaxError{i + 1, "missing operator after '" + name + "'"}
+	}
+	opPos := i
+	i += len(spec.text)
+
+	value, fold, next, err := parseValue(expression, i, spec.op == OpRegex)
+	if err != nil {
+		return nil, 0, err
+	}
+	fold = fold || spec.fold
+
+	cond := &Cond{Field: field, Op: spec.op, Value: value, Fold: fold, Column: start + 1}
+	switch {
+	case isOrdering(spec.op) && !field.numeric():
+		return nil, 0, &syntaxError{opPos + 1, "operator '" + spec.text + "' is only valid for status and body_len"}
+	case field.numeric() && spec.op != OpRegex:
+		n, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return nil, 0, &syntaxError{i + 1, "expected a number for '" + name + "', got '" + value + "'"}
+		}
+		cond.num = n
+	case spec.op == OpRegex:
+		pattern := value
+		if fold {
+			pattern = "(?i)" + pattern
+		}
+		re, reErr := regexp.Compile(pattern)
+		if reErr != nil {
+			return nil, 0, &syntaxError{i + 1, "invalid regular expression: " + reErr.Error()}
+		}
+		cond.re = re
+	case fold:
+		cond.lower = strings.ToLower(value)
+	}
+	if spec.negate {
+		return &Not{X: cond}, next, nil
+	}
+	return cond, next, nil
+}
+
+// isOrdering 判断是否为只能用于数值字段的大小比较运算符
+func isOrdering(op Operator) bool {
+	return op == OpGreater || op == OpLess || op == OpGreaterEqual || op == OpLessEqual
+}
+
+// parseValue 解析条件的值，支持带引号和不带引号两种写法。
+// 引号内 \" 表示双引号本身，其余 \x 均按 x 处理。
+// 为保持已有指纹的含义，值首尾的双引号（包括转义得到的）会被去掉。
+// raw 为true时（正则表达式）除 \" 外保留反斜杠，也不去除首尾双引号。
+// 带引号的值后面紧跟 i 表示忽略大小写，如 title=="login"i。
+func parseValue(expression string, start int, raw bool) (value string, fold bool, next int, err *syntaxError) {
+	var sb strings.Builder
+	i := start
+
+	if i < len(expression) && expression[i] == '"' {
+		i++
+		for {
+			if i >= len(expression) {
+				return "", false, 0, &syntaxError{start + 1, "unterminated quoted string"}
+			}
+			ch := expression[i]
+			if ch == '\\' && i+1 < len(expression) {
+				if raw && expression[i+1] != '"' {
+					sb.WriteByte(ch)
+				}
+				sb.WriteByte(expression[i+1])
+				i += 2
+				continue
+			}
+			if ch == '"' {
+				i++
+				break
+			}
+			sb.WriteByte(ch)
+			i++
+		}
+		if i < len(expression) && expression[i] == 'i' && (i+1 == len(expression) || isDelimiter(expression, i+1)) {
+			fold = true
+			i++
+		}
+		if i < len(expression) && !isDelimiter(expression, i) {
+			return "", false, 0, &syntaxError{i + 1, "unexpected character '" + string(expression[i]) + "' after quoted value"}
+		}
+		if raw {
+			return sb.String(), fold, i, nil
+		}
+		return strings.Trim(sb.String(), "\""), fold, i, nil
+	}
+
+	for i < len(expression) && !isDelimiter(expression, i) {
+		ch := expression[i]
+		if ch == '\\' && i+1 < len(expression) {
+			if raw && expression[i+1] != '"' {
+				sb.WriteByte(ch)
+			}
+			sb.WriteByte(expression[i+1])
+			i += 2
+			continue
+		}
+		sb.WriteByte(ch)
+		i++
+	}
+	if sb.Len() == 0 {
+		return "", false, 0, &syntaxError{start + 1, "missing value"}
+	}
+	if raw {
+		return sb.String(), false, i, nil
+	}
+	return strings.Trim(sb.String(), "\""), false, i, nil
+}
+
+// isFieldChar 字段名允许的字符
+func isFieldChar(ch byte) bool {
+	return ch == '_' || ch == '.' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// isDelimiter 判断 i 处是否为条件的结束位置
+func isDelimiter(expression string, i int) bool {
+	switch expression[i] {
+	case ' ', '\t', '\r', '\n', '(', ')':
+		return true
+	}
+	return strings.HasPrefix(expression[i:], "&&") || strings.HasPrefix(expression[i:], "||")
+}
+
+// shuntingYard 使用 Shunting Yard 算法将token转换为后缀表达式
+func shuntingYard(tokens []token) ([]token, *syntaxError) {
+	var output []token
+	var stack []token
+
+	// expectOperand 为true时下一个token应当是条件、! 或左括号
+	expectOperand := true
+	// afterNot 上一个token是 !
+	afterNot := false
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case tokCond:
+			if !expectOperand {
+				return nil, &syntaxError{tok.pos, "missing '&&' or '||' before condition"}
+			}
+			output = append(output, tok)
+			expectOperand = false
+		case tokNot:
+			if !expectOperand {
+				return nil, &syntaxError{tok.pos, "'!' cannot follow a condition, use '&& !' or '|| !'"}
+			}
+			// 一元运算符右结合，直接入栈
+			stack = append(stack, tok)
+		case tokAnd, tokOr:
+			if afterNot {
+				return nil, &syntaxError{tok.pos, "'!' must be followed by a condition or '('"}
+			}
+			if expectOperand {
+				return nil, &syntaxError{tok.pos, "missing condition before operator"}
+			}
+			for len(stack) > 0 {
+				top := stack[len(stack)-1]
+				if top.kind == tokLParen || precedence[tok.kind] > precedence[top.kind] {
+					break
+				}
+				output = append(output, top)
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, tok)
+			expectOperand = true
+		case tokLParen:
+			if !expectOperand {
+				return nil, &syntaxError{tok.pos, "missing '&&' or '||' before '('"}
+			}
+			stack = append(stack, tok)
+		case tokRParen:
+			if afterNot {
+				return nil, &syntaxError{tok.pos, "'!' must be followed by a condition or '('"}
+			}
+			if expectOperand {
+				return nil, &syntaxError{tok.pos, "missing condition before ')'"}
+			}
+			for len(stack) > 0 && stack[len(stack)-1].kind != tokLParen {
+				output = append(output, stack[len(stack)-1])
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				return nil, &syntaxError{tok.pos, "mismatched parentheses"}
+			}
+			stack = stack[:len(stack)-1]
+		}
+		afterNot = tok.kind == tokNot
+	}
+
+	if len(tokens) == 0 {
+		return nil, &syntaxError{1, "empty keyword"}
+	}
+	if afterNot {
+		return nil, &syntaxError{tokens[len(tokens)-1].pos, "'!' must be followed by a condition or '('"}
+	}
+	if expectOperand {
+		return nil, &syntaxError{tokens[len(tokens)-1].pos, "missing condition after operator"}
+	}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.kind == tokLParen {
+			return nil, &syntaxError{top.pos, "mismatched parentheses"}
+		}
+		output = append(output, top)
+		stack = stack[:len(stack)-1]
+	}
+
+	return output, nil
+}
+
+// buildTree 由后缀表达式构建表达式树
+func buildTree(postfix []token) (Node, *syntaxError) {
+	var stack []Node
+
+	for _, tok := range postfix {
+		switch tok.kind {
+		case tokCond:
+			stack = append(stack, tok.node)
+		case tokNot:
+			if len(stack) < 1 {
+				return nil, &syntaxError{tok.pos, "insufficient operands"}
+			}
+			stack[len(stack)-1] = &Not{X: stack[len(stack)-1]}
+		case tokAnd, tokOr:
+			if len(stack) < 2 {
+				return nil, &syntaxError{tok.pos, "insufficient operands"}
+			}
+			left, right := stack[len(stack)-2], stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			if tok.kind == tokAnd {
+				stack = append(stack, &And{Left: left, Right: right})
+			} else {
+				stack = append(stack, &Or{Left: left, Right: right})
+			}
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, &syntaxError{1, "invalid expression"}
+	}
+	return stack[0], nil
+}
+
+// parseExpression 解析单条指纹关键字
+func parseExpression(expression string) (Node, *syntaxError) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	postfix, err := shuntingYard(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return buildTree(postfix)
+}
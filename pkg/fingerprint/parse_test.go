@@ -0,0 +1,313 @@
+package fingerprint
+
+import (
+	"errors"
+	"httpgo/pkg/utils"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{`body="a"`, `body="a"`},
+		{`body=abc`, `body="abc"`},
+		{`body="say \"hi\" now"`, `body="say \"hi\" now"`},
+		{`body="\"hi\""`, `body="hi"`},
+		{`body="a" && header="b" || title="c"`, `((body="a" && header="b") || title="c")`},
+		{`body="a" || header="b" && title="c"`, `(body="a" || (header="b" && title="c"))`},
+		{`(body="a" || header="b") && title="c"`, `((body="a" || header="b") && title="c")`},
+
+		// ! 的优先级高于 && 和 ||
+		{`!body="a" && title="b"`, `(body!="a" && title="b")`},
+		{`!body="a" || !title="b" && header="c"`, `(body!="a" || (title!="b" && header="c"))`},
+		{`!(body="a" && title="b")`, `!(body="a" && title="b")`},
+		{`body="a" || !(title="b" || !header="c")`, `(body="a" || !(title="b" || header!="c"))`},
+		{`!!body="a"`, `!body!="a"`},
+		{`body!="a"`, `body!="a"`},
+
+		// 完全相等与忽略大小写
+		{`title=="Login"`, `title=="Login"`},
+		{`title=="login"i`, `title=="login"i`},
+		{`title="login"i&&body="b"`, `(title="login"i && body="b")`},
+		{`title*="login"`, `title="login"i`},
+		{`body!*="x"`, `body!="x"i`},
+		{`header!=="Server: nginx"`, `header!=="Server: nginx"`},
+		{`body="i"`, `body="i"`},
+
+		// 正则
+		{`body~="v(?P<version>[\d.]+)"`, `body~="v(?P<version>[\\d.]+)"`},
+		{`body~="\"id\": (?P<id>\d+)"`, `body~="\"id\": (?P<id>\\d+)"`},
+		{`body!~="debug"`, `body!~="debug"`},
+		{`title~="^admin"i`, `title~="^admin"i`},
+
+		// 其他字段
+		{`server="nginx" && content_type*="json"`, `(server="nginx" && content_type="json"i)`},
+		{`status=200 || status!=404`, `(status="200" || status!="404")`},
+		{`body_len==0`, `body_len=="0"`},
+		{`status~="^2"`, `status~="^2"`},
+		{`status>=200 && status<300`, `(status>="200" && status<"300")`},
+		{`body_len>1000 || body_len<=10`, `(body_len>"1000" || body_len<="10")`},
+		{`protocol=="h2"`, `protocol=="h2"`},
+		{`cert.cn="example.com" && cert.issuer*="let's encrypt"`, `(cert.cn="example.com" && cert.issuer="let's encrypt"i)`},
+		{`cert.san=="*.example.com"`, `cert.san=="*.example.com"`},
+		{`tls.version=="TLS 1.3"`, `tls.version=="TLS 1.3"`},
+		{`jarm=="27d40d40d29d40d1dc42d43d00041d"`, `jarm=="27d40d40d29d40d1dc42d43d00041d"`},
+		{`icon_hash="-1"`, `icon_hash="-1"`},
+	}
+	for _, tt := range tests {
+		n, err := parseExpression(tt.keyword)
+		if err != nil {
+			t.Errorf("parseExpression(%s): unexpected error at column %d: %s", tt.keyword, err.column, err.msg)
+			continue
+		}
+		if got := n.String(); got != tt.want {
+			t.Errorf("parseExpression(%s) = %s, want %s", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		keyword string
+		column  int
+		msg     string // 错误信息的前缀
+	}{
+		{``, 1, "empty keyword"},
+		{`   `, 1, "empty keyword"},
+		{`=abc`, 1, "unexpected character '='"},
+		{`body="a" & title="b"`, 10, "unexpected character '&'"},
+		{`foo="a"`, 1, "unknown field 'foo'"},
+		{`body="a" && bar="b"`, 13, "unknown field 'bar'"},
+		{`body`, 5, "missing operator after 'body'"},
+		{`body: "a"`, 5, "missing operator after 'body'"},
+		{`body=`, 6, "missing value"},
+		{`body="abc`, 6, "unterminated quoted string"},
+		{`body="a"x`, 9, "unexpected character 'x' after quoted value"},
+		{`title="a"ix`, 10, "unexpected character 'i' after quoted value"},
+		{`body~="("`, 7, "invalid regular expression"},
+		{`status=abc`, 8, "expected a number for 'status', got 'abc'"},
+		{`status>=2xx`, 9, "expected a number for 'status', got '2xx'"},
+		{`body_len>"1k"`, 10, "expected a number for 'body_len', got '1k'"},
+		{`status>`, 8, "missing value"},
+
+		// 大小比较只能用于数值字段
+		{`title>1`, 6, "operator '>' is only valid for status and body_len"},
+		{`body="a" && header<="b"`, 19, "operator '<=' is only valid for status and body_len"},
+		{`server>="nginx"`, 7, "operator '>=' is only valid for status and body_len"},
+		{`content_type<1`, 13, "operator '<' is only valid for status and body_len"},
+
+		{`body="a" title="b"`, 10, "missing '&&' or '||' before condition"},
+		{`body="a" (title="b")`, 10, "missing '&&' or '||' before '('"},
+		{`&& body="a"`, 1, "missing condition before operator"},
+		{`body="a" && || title="b"`, 13, "missing condition before operator"},
+		{`()`, 2, "missing condition before ')'"},
+		{`body="a" &&`, 10, "missing condition after operator"},
+		{`body="a")`, 9, "mismatched parentheses"},
+		{`(body="a"`, 1, "mismatched parentheses"},
+		{`body="a" && ((title="b")`, 13, "mismatched parentheses"},
+
+		// ! 的误用
+		{`!`, 1, "'!' must be followed by a condition or '('"},
+		{`! && body="a"`, 3, "'!' must be followed by a condition or '('"},
+		{`(body="a" || !)`, 15, "'!' must be followed by a condition or '('"},
+		{`body="a" && !`, 13, "'!' must be followed by a condition or '('"},
+		{`body="a" !title="b"`, 10, "'!' cannot follow a condition, use '&& !' or '|| !'"},
+		{`(body="a")!(title="b")`, 11, "'!' cannot follow a condition, use '&& !' or '|| !'"},
+	}
+	for _, tt := range tests {
+		n, err := parseExpression(tt.keyword)
+		if err == nil {
+			t.Errorf("parseExpression(%s) = %s, want error", tt.keyword, n)
+			continue
+		}
+		if err.column != tt.column || !strings.HasPrefix(err.msg, tt.msg) {
+			t.Errorf("parseExpression(%s): got column %d %q, want column %d %q", tt.keyword, err.column, err.msg, tt.column, tt.msg)
+		}
+	}
+}
+
+func TestCompileParseErrors(t *testing.T) {
+	rs, err := Compile([]utils.FingerprintFile{
+		{Name: "ok", Type: "cms", Keyword: `title="a"`},
+		{Name: "bad-field", Type: "cms", Keyword: `title="a" && foo="b"`},
+		{Name: "bad-not", Type: "cms", Keyword: `title="a" !body="b"`},
+		{Name: "bad-ordering", Type: "cms", Keyword: `status=200 && title>1`},
+	})
+
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Compile: got %v, want ParseErrors", err)
+	}
+	want := []ParseError{
+		{Index: 1, Name: "bad-field", Keyword: `title="a" && foo="b"`, Column: 14, Msg: "unknown field 'foo'"},
+		{Index: 2, Name: "bad-not", Keyword: `title="a" !body="b"`, Column: 11, Msg: "'!' cannot follow a condition, use '&& !' or '|| !'"},
+		{Index: 3, Name: "bad-ordering", Keyword: `status=200 && title>1`, Column: 20, Msg: "operator '>' is only valid for status and body_len"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Compile: got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i := range want {
+		if *errs[i] != want[i] {
+			t.Errorf("error %d: got %+v, want %+v", i, *errs[i], want[i])
+		}
+	}
+	if got := errs[0].Error(); got != `fingerprint 1 ('bad-field') column 14: unknown field 'foo': 'title="a" && foo="b"'` {
+		t.Errorf("Error() = %s", got)
+	}
+	if len(rs.Rules) != 1 || rs.Rules[0].Name != "ok" {
+		t.Errorf("Compile should keep the valid rules, got %d", len(rs.Rules))
+	}
+}
+
+func TestMatch(t *testing.T) {
+	in := &Input{
+		Body:       `<title>Admin LOGIN</title> jQuery v3.6.0 {"id": 42}`,
+		Header:     "Server: nginx/1.18.0\nContent-Type: application/json; charset=utf-8\nX-Powered-By: PHP\n",
+		Title:      "Admin LOGIN",
+		Cert:       "Certificate:\n  Subject: CN=example.com\n",
+		IconHashes: []string{"116323821", "-1"},
+
+		Server:      "nginx/1.18.0",
+		ContentType: "application/json; charset=utf-8",
+		StatusCode:  200,
+		BodyLen:     51,
+		Protocol:    "h2",
+
+		CertCN:     "example.com",
+		CertIssuer: "Let's Encrypt",
+		CertSAN:    "example.com\n*.example.com",
+		TLSVersion: "TLS 1.3",
+		JARM:       "27d40d40d29d40d1dc42d43d00041d",
+	}
+
+	tests := []struct {
+		keyword string
+		want    bool
+	}{
+		// = 包含，区分大小写
+		{`body="jQuery"`, true},
+		{`body="jquery"`, false},
+		{`body!="jquery"`, true},
+		{`title="Admin"`, true},
+
+		// == 完全相等
+		{`title=="Admin LOGIN"`, true},
+		{`title=="Admin"`, false},
+		{`title!=="Admin"`, true},
+		{`header=="Server: nginx/1.18.0"`, true},
+		{`header=="Server: nginx"`, false},
+		{`cert.san=="*.example.com"`, true},
+		{`cert.san=="example"`, false},
+
+		// 忽略大小写
+		{`title*="admin login"`, true},
+		{`title=="admin login"i`, true},
+		{`title=="admin"i`, false},
+		{`body="JQUERY"i`, true},
+		{`body!*="JQUERY"`, false},
+		{`header*="x-powered-by: php"`, true},
+
+		// 正则
+		{`body~="jQuery v[\d.]+"`, true},
+		{`body~="^jQuery"`, false},
+		{`body!~="debug"`, true},
+		{`title~="^admin"i`, true},
+		{`body~="\"id\": \d+"`, true},
+
+		// !、&& 和 ||
+		{`!body="jquery"`, true},
+		{`!(body="jQuery" && title="Admin")`, false},
+		{`!body="jQuery" || title="Admin"`, true},
+		{`!(body="jQuery" || title="x") && title="Admin"`, false},
+		{`body="x" || title="x" || !header="zzz"`, true},
+		{`!!body="jQuery"`, true},
+
+		// 响应头、状态码与长度
+		{`server="nginx"`, true},
+		{`server=="nginx"`, false},
+		{`content_type*="JSON"`, true},
+		{`status=200`, true},
+		{`status==200`, true},
+		{`status!=200`, false},
+		{`status=20`, false},
+		{`status~="^2\d\d$"`, true},
+		{`body_len=51`, true},
+		{`status>199`, true},
+		{`status>200`, false},
+		{`status>=200`, true},
+		{`status<200`, false},
+		{`status<=200`, true},
+		{`status>=200 && status<300`, true},
+		{`status>=400 || status<100`, false},
+		{`!status>=400`, true},
+		{`body_len>50`, true},
+		{`body_len>51`, false},
+		{`body_len<1000`, true},
+		{`body_len>=51 && body_len<=51`, true},
+		{`body_len<=50`, false},
+		{`protocol=="h2"`, true},
+		{`protocol=="http/1.1"`, false},
+
+		// 证书、TLS 与 JARM
+		{`cert="CN=example.com"`, true},
+		{`cert.cn=="example.com"`, true},
+		{`cert.issuer*="let's encrypt"`, true},
+		{`tls.version=="TLS 1.3"`, true},
+		{`jarm=="27d40d40d29d40d1dc42d43d00041d"`, true},
+
+		// icon_hash 的 = 表示与任一hash完全相等
+		{`icon_hash="116323821"`, true},
+		{`icon_hash="-1"`, true},
+		{`icon_hash="11632"`, false},
+		{`icon_hash~="^1163"`, true},
+	}
+	for _, tt := range tests {
+		n, err := parseExpression(tt.keyword)
+		if err != nil {
+			t.Errorf("parseExpression(%s): unexpected error: %s", tt.keyword, err.msg)
+			continue
+		}
+		if got := n.Match(in); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestMatchExtracted(t *testing.T) {
+	rs, err := Compile([]utils.FingerprintFile{
+		{Name: "jquery", Type: "other", Keyword: `body~="jQuery v(?P<version>[\d.]+)"`},
+		{Name: "nginx", Type: "cms", Keyword: `server=="nginx/1.18.0" && server~="/(?P<version>[\d.]+)$"`},
+		{Name: "or", Type: "other", Keyword: `body~="(?P<missing>nothing)" || title~="(?P<role>[A-Z][a-z]+) (?P<page>\w+)"`},
+		{Name: "not", Type: "other", Keyword: `!body~="(?P<debug>debug)" && body*="JQUERY"`},
+		{Name: "fold", Type: "other", Keyword: `title~="(?P<page>login)"i`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := &Input{
+		Body:   "jQuery v3.6.0",
+		Title:  "Admin LOGIN",
+		Server: "nginx/1.18.0",
+	}
+
+	cms, other, extracted := rs.Match(in)
+	if !reflect.DeepEqual(cms, []string{"nginx"}) {
+		t.Errorf("cms = %v", cms)
+	}
+	if !reflect.DeepEqual(other, []string{"jquery", "or", "not", "fold"}) {
+		t.Errorf("other = %v", other)
+	}
+	want := map[string]map[string]string{
+		"jquery": {"version": "3.6.0"},
+		"nginx":  {"version": "1.18.0"},
+		"or":     {"role": "Admin", "page": "LOGIN"},
+		"fold":   {"page": "LOGIN"},
+	}
+	if !reflect.DeepEqual(extracted, want) {
+		t.Errorf("extracted = %v, want %v", extracted, want)
+	}
+}
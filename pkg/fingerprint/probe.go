@@ -0,0 +1,196 @@
+package fingerprint
+
+import (
+	"context"
+	"fmt"
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/utils"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Probe 规则需要额外请求的路径，规则的 keyword 匹配该路径的响应
+type Probe struct {
+	Path    string
+	Method  string
+	Headers map[string]string
+	Body    string
+}
+
+// newProbe 由指纹文件中的 path/method/headers/body 构建 Probe，未设置 path 时返回 nil
+func newProbe(fp utils.FingerprintFile) (*Probe, error) {
+	if fp.Path == "" {
+		if fp.Method != "" || len(fp.Headers) > 0 || fp.Body != "" {
+			return nil, fmt.Errorf("method, headers and body require path")
+		}
+		return nil, nil
+	}
+	if !strings.HasPrefix(fp.Path, "/") {
+		return nil, fmt.Errorf("path '%s' must start with '/'", fp.Path)
+	}
+	if _, err := url.Parse(fp.Path); err != nil {
+		return nil, fmt.Errorf("invalid path '%s': %v", fp.Path, err)
+	}
+
+	method := strings.ToUpper(fp.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	for _, ch := range method {
+		if ch < 'A' || ch > 'Z' {
+			return nil, fmt.Errorf("invalid method '%s'", fp.Method)
+		}
+	}
+
+	return &Probe{
+		Path:    fp.Path,
+		Method:  method,
+		Headers: fp.Headers,
+		Body:    fp.Body,
+	}, nil
+}
+
+// Key 探测请求的唯一标识，相同 Key 的规则共用一次请求
+func (p *Probe) Key() string {
+	keys := make([]string, 0, len(p.Headers))
+	for k := range p.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(p.Method + " " + p.Path + "\n")
+	for _, k := range keys {
+		sb.WriteString(http.CanonicalHeaderKey(k) + ": " + p.Headers[k] + "\n")
+	}
+	sb.WriteString("\n" + p.Body)
+	return sb.String()
+}
+
+// URL 将探测路径拼接到目标的 scheme://host 上
+func (p *Probe) URL(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	return u.Scheme + "://" + u.Host + p.Path, nil
+}
+
+// probeGroup 共用同一探测请求的规则
+type probeGroup struct {
+	probe *Probe
+	rules []*Rule
+}
+
+// groupProbes 将需要路径探测的规则按请求去重分组
+func groupProbes(rules []*Rule) ([]*probeGroup, map[string]*probeGroup) {
+	var groups []*probeGroup
+	index := make(map[string]*probeGroup)
+	for _, r := range rules {
+		if r.Probe == nil {
+			continue
+		}
+		key := r.Probe.Key()
+		g, ok := index[key]
+		if !ok {
+			g = &probeGroup{probe: r.Probe}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.rules = append(g.rules, r)
+	}
+	return groups, index
+}
+
+// Probes 返回去重后的全部探测请求，每个目标对每个探测请求只需发送一次
+func (rs *RuleSet) Probes() []*Probe {
+	probes := make([]*Probe, 0, len(rs.probes))
+	for _, g := range rs.probes {
+		probes = append(probes, g.probe)
+	}
+	return probes
+}
+
+// MatchProbe 用探测请求的响应匹配依赖该请求的规则
+func (rs *RuleSet) MatchProbe(p *Probe, in *Input) (cms []string, other []string, extracted map[string]map[string]string) {
+	g, ok := rs.probeIndex[p.Key()]
+	if !ok {
+		return nil, nil, nil
+	}
+	cms, other, extracted = matchRules(g.rules, in)
+	return httpgo.RemoveDuplicates(cms), httpgo.RemoveDuplicates(other), extracted
+}
+
+// ProbeCache 一次扫描中探测请求的响应，按 scheme://host:port 和 Probe.Key() 缓存。
+// 同一主机出现在多个目标中（如重复的目标、路径不同的url）时，每个探测请求只发送一次。
+// 可被多个goroutine并发使用，零值可直接使用
+type ProbeCache struct {
+	m sync.Map // origin + "\n" + Probe.Key() -> *probeEntry
+}
+
+// probeEntry 单个探测请求的结果，done 关闭后 resp 可读，请求失败时 resp 为 nil
+type probeEntry struct {
+	done chan struct{}
+	resp *httpgo.Response
+}
+
+// NewProbeCache 创建探测请求缓存
+func NewProbeCache() *ProbeCache {
+	return &ProbeCache{}
+}
+
+// Get 返回 target 所在主机上探测请求的响应，未缓存时发送请求。
+// 同一请求同时被多个目标需要时只发送一次，其余调用等待该请求完成。请求失败时返回 nil
+func (c *ProbeCache) Get(ctx context.Context, client *httpgo.Client, p *Probe, target string) *httpgo.Response {
+	probeURL, err := p.URL(target)
+	if err != nil {
+		return nil
+	}
+	if c == nil {
+		return sendProbe(ctx, client, p, probeURL)
+	}
+
+	e := &probeEntry{done: make(chan struct{})}
+	v, loaded := c.m.LoadOrStore(probeOrigin(probeURL)+"\n"+p.Key(), e)
+	if loaded {
+		e = v.(*probeEntry)
+		select {
+		case <-e.done:
+			return e.resp
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	e.resp = sendProbe(ctx, client, p, probeURL)
+	close(e.done)
+	return e.resp
+}
+
+// sendProbe 发送探测请求，请求失败时返回 nil
+func sendProbe(ctx context.Context, client *httpgo.Client, p *Probe, probeURL string) *httpgo.Response {
+	resp, err := client.SendRequest(ctx, p.Method, probeURL, p.Headers, p.Body)
+	if err != nil || resp.StatusCode == -1 {
+		return nil
+	}
+	return resp
+}
+
+// probeOrigin 返回 scheme://host:port，省略的端口补全为默认端口
+func probeOrigin(probeURL string) string {
+	u, err := url.Parse(probeURL)
+	if err != nil {
+		return probeURL
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return u.Scheme + "://" + net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
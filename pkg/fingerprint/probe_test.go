@@ -0,0 +1,141 @@
+package fingerprint
+
+import (
+	"context"
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetFingerProbes(t *testing.T) {
+	type request struct {
+		method, path, token, body string
+	}
+	var mu sync.Mutex
+	var probes []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			probes = append(probes, request{r.Method, r.URL.Path, r.Header.Get("X-Token"), string(body)})
+			mu.Unlock()
+			io.WriteString(w, `{"product": "probe-ok"}`)
+		case "/other":
+			mu.Lock()
+			probes = append(probes, request{r.Method, r.URL.Path, r.Header.Get("X-Token"), ""})
+			mu.Unlock()
+			io.WriteString(w, "other")
+		case "/favicon.ico":
+			http.NotFound(w, r)
+		default:
+			io.WriteString(w, "<title>index</title>")
+		}
+	}))
+	defer srv.Close()
+
+	headers := map[string]string{"X-Token": "abc"}
+	rules, err := Compile([]utils.FingerprintFile{
+		{Name: "api", Type: "cms", Keyword: `body="probe-ok"`, Path: "/api", Method: "post", Headers: headers, Body: "q=1"},
+		{Name: "api-json", Type: "other", Keyword: `content_type*="text/plain" && body~="\"product\": \"(?P<product>[\w-]+)\""`, Path: "/api", Method: "POST", Headers: headers, Body: "q=1"},
+		{Name: "index-only", Type: "cms", Keyword: `body="probe-ok"`},
+		{Name: "other-path", Type: "cms", Keyword: `body="probe-ok"`, Path: "/other"},
+		{Name: "index", Type: "other", Keyword: `title=="index"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := httpgo.NewClient(httpgo.ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewProbeCache()
+	// 同一主机以不同的url出现多次，每个探测请求只发送一次
+	for _, target := range []string{srv.URL, srv.URL + "/", srv.URL + "/login"} {
+		a, err := GetFinger(context.Background(), target, client, rules, cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(a.CmsList, []string{"api"}) || !reflect.DeepEqual(a.OtherList, []string{"index", "api-json"}) {
+			t.Errorf("%s: cms %v, other %v", target, a.CmsList, a.OtherList)
+		}
+		if want := map[string]map[string]string{"api-json": {"product": "probe-ok"}}; !reflect.DeepEqual(a.Extracted, want) {
+			t.Errorf("%s: extracted %v", target, a.Extracted)
+		}
+	}
+
+	want := []request{
+		{"POST", "/api", "abc", "q=1"},
+		{"GET", "/other", "", ""},
+	}
+	if !reflect.DeepEqual(probes, want) {
+		t.Errorf("probe requests = %v, want %v", probes, want)
+	}
+
+	// 不使用缓存时每个目标都重新请求
+	probes = nil
+	for i := 0; i < 2; i++ {
+		if _, err := GetFinger(context.Background(), srv.URL, client, rules, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(probes) != 4 {
+		t.Errorf("got %d probe requests without cache, want 4", len(probes))
+	}
+}
+
+func TestProbeCacheConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	count := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client, err := httpgo.NewClient(httpgo.ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewProbeCache()
+	probe := &Probe{Path: "/probe", Method: http.MethodGet}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r := cache.Get(context.Background(), client, probe, srv.URL+"/x"); r == nil || string(r.Body) != "ok" {
+				t.Errorf("Get = %v", r)
+			}
+		}()
+	}
+	wg.Wait()
+	if count != 1 {
+		t.Errorf("got %d requests, want 1", count)
+	}
+}
+
+func TestProbeOrigin(t *testing.T) {
+	tests := map[string]string{
+		"http://Example.com/a":      "http://example.com:80",
+		"https://example.com/a":     "https://example.com:443",
+		"https://example.com:8443/": "https://example.com:8443",
+		"http://[::1]/a":            "http://[::1]:80",
+	}
+	for in, want := range tests {
+		if got := probeOrigin(in); got != want {
+			t.Errorf("probeOrigin(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
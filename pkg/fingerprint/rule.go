@@ -0,0 +1,540 @@
+package fingerprint
+
+import (
+	"fmt"
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/utils"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Field 指纹规则可匹配的字段
+type Field string
+
+const (
+	FieldBody     Field = "body"
+	FieldHeader   Field = "header"
+	FieldTitle    Field = "title"
+	FieldCert     Field = "cert"
+	FieldIconHash Field = "icon_hash"
+
+	FieldServer      Field = "server"       // Server 响应头
+	FieldContentType Field = "content_type" // Content-Type 响应头
+	FieldStatus      Field = "status"       // 状态码
+	FieldBodyLen     Field = "body_len"     // body 长度（字节）
+	FieldProtocol    Field = "protocol"     // 使用的协议：http/1.0、http/1.1、h2
+
+	FieldCertCN     Field = "cert.cn"     // 站点证书的 CN
+	FieldCertIssuer Field = "cert.issuer" // 站点证书的签发者
+	FieldCertSAN    Field = "cert.san"    // 站点证书的 SAN，每行一个
+	FieldTLSVersion Field = "tls.version" // 协商的 TLS 版本，如 TLS 1.2
+	FieldJARM       Field = "jarm"        // JARM 指纹，开启 JARM 时才有值
+)
+
+// fields 所有支持的字段
+var fields = map[string]Field{
+	"body":         FieldBody,
+	"header":       FieldHeader,
+	"title":        FieldTitle,
+	"cert":         FieldCert,
+	"icon_hash":    FieldIconHash,
+	"server":       FieldServer,
+	"content_type": FieldContentType,
+	"status":       FieldStatus,
+	"body_len":     FieldBodyLen,
+	"protocol":     FieldProtocol,
+	"cert.cn":      FieldCertCN,
+	"cert.issuer":  FieldCertIssuer,
+	"cert.san":     FieldCertSAN,
+	"tls.version":  FieldTLSVersion,
+	"jarm":         FieldJARM,
+}
+
+// numeric 数值字段支持 > < >= <= 比较
+func (f Field) numeric() bool {
+	return f == FieldStatus || f == FieldBodyLen
+}
+
+// Operator 条件中字段与值之间的比较方式
+type Operator string
+
+const (
+	OpContains Operator = "="  // 包含
+	OpEquals   Operator = "==" // 完全相等
+	OpRegex    Operator = "~=" // 正则匹配
+
+	// 以下仅用于数值字段
+	OpGreater      Operator = ">"
+	OpLess         Operator = "<"
+	OpGreaterEqual Operator = ">="
+	OpLessEqual    Operator = "<="
+)
+
+// Input 规则求值时使用的响应内容，每个目标构建一次，供所有规则共享
+type Input struct {
+	Body       string
+	Header     string
+	Title      string
+	Cert       string
+	IconHashes []string
+
+	Server      string
+	ContentType string
+	StatusCode  int
+	BodyLen     int
+	Protocol    string
+
+	CertCN     string
+	CertIssuer string
+	CertSAN    string
+	TLSVersion string
+	JARM       string
+
+	mu    sync.Mutex
+	lower map[Field]string // 忽略大小写匹配时使用的小写文本，按需生成
+}
+
+// NewInput 根据响应和favicon hash构建规则求值的输入
+func NewInput(resp *httpgo.Response, favicons *httpgo.FaviconList) *Input {
+	in := &Input{
+		Body:   string(resp.Body),
+		Header: resp.HeadersStr,
+		Title:  resp.Title,
+		Cert:   resp.Cert,
+
+		Server:      headerValue(resp.HeadersMap, "Server"),
+		ContentType: headerValue(resp.HeadersMap, "Content-Type"),
+		StatusCode:  resp.StatusCode,
+		BodyLen:     len(resp.Body),
+		Protocol:    resp.Protocol,
+	}
+	if favicons != nil {
+		in.IconHashes = favicons.FaviconHash
+	}
+	if resp.TLS != nil {
+		in.TLSVersion = resp.TLS.Version
+	}
+	if leaf := resp.TLS.Leaf(); leaf != nil {
+		in.CertCN = leaf.CommonName
+		in.CertIssuer = leaf.Issuer
+		in.CertSAN = strings.Join(leaf.SANs, "\n")
+	}
+	return in
+}
+
+// headerValue 取响应头的值，多个值用逗号连接
+func headerValue(headers map[string][]string, key string) string {
+	return strings.Join(http.Header(headers).Values(key), ", ")
+}
+
+// number 取数值字段的值
+func (in *Input) number(f Field) int {
+	switch f {
+	case FieldStatus:
+		return in.StatusCode
+	case FieldBodyLen:
+		return in.BodyLen
+	}
+	return 0
+}
+
+// text 取字段对应的文本内容
+func (in *Input) text(f Field) string {
+	switch f {
+	case FieldServer:
+		return in.Server
+	case FieldContentType:
+		return in.ContentType
+	case FieldProtocol:
+		return in.Protocol
+	case FieldStatus, FieldBodyLen:
+		return strconv.Itoa(in.number(f))
+	case FieldBody:
+		return in.Body
+	case FieldHeader:
+		return in.Header
+	case FieldTitle:
+		return in.Title
+	case FieldCert:
+		return in.Cert
+	case FieldCertCN:
+		return in.CertCN
+	case FieldCertIssuer:
+		return in.CertIssuer
+	case FieldCertSAN:
+		return in.CertSAN
+	case FieldTLSVersion:
+		return in.TLSVersion
+	case FieldJARM:
+		return in.JARM
+	}
+	return ""
+}
+
+// lowerText 取字段对应文本的小写形式，结果会被缓存
+func (in *Input) lowerText(f Field) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if s, ok := in.lower[f]; ok {
+		return s
+	}
+	if in.lower == nil {
+		in.lower = make(map[Field]string)
+	}
+	s := strings.ToLower(in.text(f))
+	in.lower[f] = s
+	return s
+}
+
+// Node 编译后的表达式树节点，编译完成后不可修改，可被多个goroutine并发使用
+type Node interface {
+	Match(in *Input) bool
+	String() string
+}
+
+// Cond 单个条件，如 body="xxx"
+type Cond struct {
+	Field  Field
+	Op     Operator
+	Value  string
+	Fold   bool // 忽略大小写
+	Column int  // 条件在关键字中的起始列（从1开始）
+
+	re    *regexp.Regexp // OpRegex 编译后的正则
+	lower string         // Fold 时 Value 的小写形式
+	num   int            // 数值字段比较的值
+}
+
+func (c *Cond) Match(in *Input) bool {
+	switch {
+	case c.Field.numeric() && c.Op != OpRegex:
+		return c.compare(in.number(c.Field))
+	case c.Field == FieldIconHash:
+		// icon_hash 的 = 表示与任一hash完全相等
+		for _, hash := range in.IconHashes {
+			if c.Op == OpRegex && c.re.MatchString(hash) || c.Op != OpRegex && c.equals(hash) {
+				return true
+			}
+		}
+		return false
+	case c.Op == OpRegex:
+		return c.re.MatchString(in.text(c.Field))
+	case c.Op == OpEquals && (c.Field == FieldHeader || c.Field == FieldCert || c.Field == FieldCertSAN):
+		// header、cert 和 cert.san 为多行文本，== 与其中任一行完全相等即可
+		for _, line := range strings.Split(in.text(c.Field), "\n") {
+			if c.equals(strings.TrimSpace(line)) {
+				return true
+			}
+		}
+		return false
+	case c.Op == OpEquals:
+		return c.equals(in.text(c.Field))
+	case c.Fold:
+		return strings.Contains(in.lowerText(c.Field), c.lower)
+	}
+	return strings.Contains(in.text(c.Field), c.Value)
+}
+
+// compare 数值比较，= 与 == 均表示相等
+func (c *Cond) compare(n int) bool {
+	switch c.Op {
+	case OpGreater:
+		return n > c.num
+	case OpLess:
+		return n < c.num
+	case OpGreaterEqual:
+		return n >= c.num
+	case OpLessEqual:
+		return n <= c.num
+	}
+	return n == c.num
+}
+
+// equals 判断是否完全相等
+func (c *Cond) equals(s string) bool {
+	if c.Fold {
+		return strings.EqualFold(s, c.Value)
+	}
+	return s == c.Value
+}
+
+// extract 提取正则中命名分组的内容
+func (c *Cond) extract(in *Input, out map[string]string) {
+	if c.Op != OpRegex || c.re.NumSubexp() == 0 {
+		return
+	}
+	var texts []string
+	if c.Field == FieldIconHash {
+		texts = in.IconHashes
+	} else {
+		texts = []string{in.text(c.Field)}
+	}
+	for _, text := range texts {
+		m := c.re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		for i, name := range c.re.SubexpNames() {
+			if name != "" && m[i] != "" {
+				out[name] = m[i]
+			}
+		}
+		return
+	}
+}
+
+func (c *Cond) String() string {
+	return c.format(false)
+}
+
+// format 还原条件的文本形式，negate 为true时输出取反的运算符
+func (c *Cond) format(negate bool) string {
+	op := string(c.Op)
+	if negate {
+		op = "!" + op
+	}
+	flag := ""
+	if c.Fold {
+		flag = "i"
+	}
+	return fmt.Sprintf("%s%s%q%s", c.Field, op, c.Value, flag)
+}
+
+// And 逻辑与
+type And struct {
+	Left, Right Node
+}
+
+func (n *And) Match(in *Input) bool {
+	return n.Left.Match(in) && n.Right.Match(in)
+}
+
+func (n *And) String() string {
+	return "(" + n.Left.String() + " && " + n.Right.String() + ")"
+}
+
+// Or 逻辑或
+type Or struct {
+	Left, Right Node
+}
+
+func (n *Or) Match(in *Input) bool {
+	return n.Left.Match(in) || n.Right.Match(in)
+}
+
+func (n *Or) String() string {
+	return "(" + n.Left.String() + " || " + n.Right.String() + ")"
+}
+
+// Not 逻辑非，前缀 ! 以及 != 等取反的条件均编译为 Not
+type Not struct {
+	X Node
+}
+
+func (n *Not) Match(in *Input) bool {
+	return !n.X.Match(in)
+}
+
+func (n *Not) String() string {
+	if c, ok := n.X.(*Cond); ok {
+		return c.format(true)
+	}
+	return "!" + n.X.String()
+}
+
+// extract 收集已匹配的表达式中正则命名分组提取到的内容，取反的分支不参与提取
+func extract(n Node, in *Input, out map[string]string) {
+	switch x := n.(type) {
+	case *Cond:
+		x.extract(in, out)
+	case *And:
+		extract(x.Left, in, out)
+		extract(x.Right, in, out)
+	case *Or:
+		if x.Left.Match(in) {
+			extract(x.Left, in, out)
+		}
+		if x.Right.Match(in) {
+			extract(x.Right, in, out)
+		}
+	}
+}
+
+// Rule 编译后的单条指纹规则
+type Rule struct {
+	Index int // 在指纹文件中的序号
+	Name  string
+	Type  string
+	Expr  Node
+	Probe *Probe // 不为空时匹配该探测请求的响应，而不是目标本身的响应
+
+	hasCapture bool // 表达式中含有带命名分组的正则
+}
+
+// RuleSet 编译后的全部指纹规则，启动时构建一次，所有扫描goroutine共享
+type RuleSet struct {
+	Rules []*Rule
+
+	prefilter  *prefilter
+	probes     []*probeGroup
+	probeIndex map[string]*probeGroup
+}
+
+// ParseError 指纹规则的语法错误
+type ParseError struct {
+	Index   int    // 规则在指纹文件中的序号
+	Name    string // 规则名称
+	Keyword string
+	Column  int // 出错位置（从1开始），0 表示错误不在 keyword 中
+	Msg     string
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("fingerprint %d ('%s'): %s: '%s'", e.Index, e.Name, e.Msg, e.Keyword)
+	}
+	return fmt.Sprintf("fingerprint %d ('%s') column %d: %s: '%s'", e.Index, e.Name, e.Column, e.Msg, e.Keyword)
+}
+
+// ParseErrors 编译时收集到的全部语法错误
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	var sb strings.Builder
+	for i, e := range errs {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(e.Error())
+	}
+	return sb.String()
+}
+
+// Compile 将指纹规则编译为表达式树。
+// 存在语法错误时返回 ParseErrors，同时返回的 RuleSet 中仍包含其余编译成功的规则。
+func Compile(fingerlist []utils.FingerprintFile) (*RuleSet, error) {
+	rs := &RuleSet{Rules: make([]*Rule, 0, len(fingerlist))}
+	var errs ParseErrors
+
+	for i, fp := range fingerlist {
+		expr, err := parseExpression(fp.Keyword)
+		if err != nil {
+			errs = append(errs, &ParseError{
+				Index:   i,
+				Name:    fp.Name,
+				Keyword: fp.Keyword,
+				Column:  err.column,
+				Msg:     err.msg,
+			})
+			continue
+		}
+		probe, probeErr := newProbe(fp)
+		if probeErr != nil {
+			errs = append(errs, &ParseError{
+				Index:   i,
+				Name:    fp.Name,
+				Keyword: fp.Keyword,
+				Msg:     probeErr.Error(),
+			})
+			continue
+		}
+		rs.Rules = append(rs.Rules, &Rule{
+			Index:      i,
+			Name:       fp.Name,
+			Type:       fp.Type,
+			Expr:       expr,
+			Probe:      probe,
+			hasCapture: hasCapture(expr),
+		})
+	}
+
+	rs.prefilter = newPrefilter(rs.Rules)
+	rs.probes, rs.probeIndex = groupProbes(rs.Rules)
+
+	if len(errs) > 0 {
+		return rs, errs
+	}
+	return rs, nil
+}
+
+// hasCapture 判断表达式中是否有带命名分组的正则
+func hasCapture(n Node) bool {
+	switch x := n.(type) {
+	case *Cond:
+		if x.re != nil {
+			for _, name := range x.re.SubexpNames() {
+				if name != "" {
+					return true
+				}
+			}
+		}
+	case *And:
+		return hasCapture(x.Left) || hasCapture(x.Right)
+	case *Or:
+		return hasCapture(x.Left) || hasCapture(x.Right)
+	}
+	return false
+}
+
+// Match 用目标本身的响应匹配规则（不含需要路径探测的规则），
+// 返回匹配到的cms指纹和其他指纹名称（已去重），以及正则命名分组提取到的内容（指纹名称 -> 分组名 -> 值）。
+// 由 Compile 构建的 RuleSet 会先经过字面量预过滤，只对可能匹配的规则求值。
+func (rs *RuleSet) Match(in *Input) (cms []string, other []string, extracted map[string]map[string]string) {
+	rules := make([]*Rule, 0, 16)
+	if rs.prefilter != nil {
+		for _, i := range rs.prefilter.candidates(in, len(rs.Rules)) {
+			rules = append(rules, rs.Rules[i])
+		}
+	} else {
+		for _, r := range rs.Rules {
+			if r.Probe == nil {
+				rules = append(rules, r)
+			}
+		}
+	}
+
+	cms, other, extracted = matchRules(rules, in)
+	return httpgo.RemoveDuplicates(cms), httpgo.RemoveDuplicates(other), extracted
+}
+
+// matchRules 对给定的规则逐条求值
+func matchRules(rules []*Rule, in *Input) (cms []string, other []string, extracted map[string]map[string]string) {
+	for _, r := range rules {
+		if !r.Expr.Match(in) {
+			continue
+		}
+		if r.Type == "cms" {
+			cms = append(cms, r.Name)
+		} else {
+			other = append(other, r.Name)
+		}
+		if r.hasCapture {
+			values := make(map[string]string)
+			extract(r.Expr, in, values)
+			extracted = MergeExtracted(extracted, map[string]map[string]string{r.Name: values})
+		}
+	}
+	return cms, other, extracted
+}
+
+// MergeExtracted 将 src 中提取到的内容合并到 dst，返回合并后的结果
+func MergeExtracted(dst, src map[string]map[string]string) map[string]map[string]string {
+	for name, values := range src {
+		if len(values) == 0 {
+			continue
+		}
+		if dst == nil {
+			dst = make(map[string]map[string]string)
+		}
+		if dst[name] == nil {
+			dst[name] = make(map[string]string)
+		}
+		for k, v := range values {
+			dst[name][k] = v
+		}
+	}
+	return dst
+}
@@ -0,0 +1,134 @@
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/screenshot"
+	"sync"
+	"time"
+)
+
+// Hooks 扫描过程中的回调，均可为 nil，可能被多个goroutine并发调用
+type Hooks struct {
+	BeforeScan func(target string)  // 开始扫描目标前调用
+	AfterScan  func(result *Result) // 目标扫描完成后调用
+}
+
+// ScannerOptions 扫描器配置
+type ScannerOptions struct {
+	Client      *httpgo.Client   // 为空时使用默认配置创建
+	Rules       *RuleSet         // 编译后的指纹规则，必须设置
+	Concurrency int              // ScanStream 的并发数，0 使用默认值 20
+	Rate        float64          // 每秒最多开始扫描的目标数，0 表示不限制
+	Screenshots *screenshot.Pool // 为 nil 时不截图
+	Hooks       Hooks
+}
+
+// Result 单个目标的扫描结果
+type Result struct {
+	Target  string
+	Fingers *Fingers
+	Err     error // 目标被取消时为 ctx.Err()，此时 Fingers 可能不完整
+}
+
+// Scanner 指纹扫描器，可被多个goroutine并发使用
+type Scanner struct {
+	client      *httpgo.Client
+	rules       *RuleSet
+	probes      *ProbeCache // 路径探测的响应，整个扫描共享
+	concurrency int
+	limiter     *httpgo.RateLimiter
+	screenshots *screenshot.Pool
+	hooks       Hooks
+}
+
+// DefaultConcurrency ScanStream 的默认并发数
+const DefaultConcurrency = 20
+
+// NewScanner 根据配置创建扫描器
+func NewScanner(opts ScannerOptions) (*Scanner, error) {
+	if opts.Rules == nil {
+		return nil, errors.New("scanner requires compiled rules")
+	}
+	client := opts.Client
+	if client == nil {
+		var err error
+		client, err = httpgo.NewClient(httpgo.ClientOptions{Timeout: 8 * time.Second, CertExpiryDays: httpgo.DefaultCertExpiryDays})
+		if err != nil {
+			return nil, err
+		}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &Scanner{
+		client:      client,
+		rules:       opts.Rules,
+		probes:      NewProbeCache(),
+		concurrency: concurrency,
+		limiter:     httpgo.NewRateLimiter(opts.Rate, 1),
+		screenshots: opts.Screenshots,
+		hooks:       opts.Hooks,
+	}, nil
+}
+
+// Scan 扫描单个目标。ctx 取消时返回 ctx.Err()，已取得的部分结果仍会返回
+func (s *Scanner) Scan(ctx context.Context, target string) (*Fingers, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if s.hooks.BeforeScan != nil {
+		s.hooks.BeforeScan(target)
+	}
+
+	a, err := GetFinger(ctx, target, s.client, s.rules, s.probes)
+	if err == nil && s.screenshots != nil && a.StatusCode != -1 {
+		// 截图失败不影响指纹结果
+		a.Screenshot, _ = s.screenshots.Capture(ctx, a.Url)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	if s.hooks.AfterScan != nil {
+		s.hooks.AfterScan(&Result{Target: target, Fingers: a, Err: err})
+	}
+	return a, err
+}
+
+// ScanStream 并发扫描 targets 中的目标，结果的顺序与输入无关。
+// targets 关闭或 ctx 取消后，返回的通道在所有进行中的目标结束后关闭；调用方需读完该通道。
+func (s *Scanner) ScanStream(ctx context.Context, targets <-chan string) <-chan Result {
+	results := make(chan Result, s.concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var target string
+				var ok bool
+				select {
+				case target, ok = <-targets:
+				case <-ctx.Done():
+					return
+				}
+				if !ok {
+					return
+				}
+
+				a, err := s.Scan(ctx, target)
+				results <- Result{Target: target, Fingers: a, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
@@ -0,0 +1,179 @@
+package httpgo
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOptions 扫描客户端配置，整个扫描过程只构建一次
+type ClientOptions struct {
+	Proxy     string        // 代理地址，为空表示不使用代理
+	Timeout   time.Duration // 单个请求的超时时间
+	UserAgent string        // 为空时每个请求随机选择 User-Agent
+
+	MaxConnsPerHost     int // 每个主机的最大连接数，0 表示不限制
+	MaxIdleConns        int // 全部主机的最大空闲连接数，0 表示不限制
+	MaxIdleConnsPerHost int // 每个主机的最大空闲连接数，0 使用默认值 2
+
+	TLSConfig *tls.Config // 为空时使用兼容老旧服务器的默认配置
+	HTTP2     bool        // 是否通过 ALPN 尝试 HTTP/2，默认只使用 HTTP/1.1
+
+	Redirect            RedirectPolicy // 跳转跟随策略，为空时跟随全部跳转
+	MaxRedirects        int            // 最大跳转次数，0 使用默认值 10
+	FollowHTMLRedirects bool           // 是否跟随 meta refresh 和 JavaScript 跳转
+
+	Rate               float64 // 全局每秒最大请求数，0 表示不限制
+	PerHostRate        float64 // 每个IP每秒最大请求数，0 表示不限制
+	PerHostConcurrency int     // 每个IP的最大并发请求数，0 表示不限制
+
+	Retry RetryPolicy // GET/HEAD 请求超时、连接重置或 5xx 时的重试策略
+
+	JARM bool // 是否计算 HTTPS 目标的 JARM 指纹，每个目标额外建立10个TLS连接
+
+	CertExpiryDays int  // 证书在多少天内到期时记录为问题，0 或小于0只记录已过期的证书
+	TLSCheck       bool // 是否探测服务器接受的 TLS 1.0/1.1 和 RC4、3DES 密码套件，每个目标额外建立4个TLS连接
+}
+
+// Client 复用连接池的HTTP客户端，可被多个goroutine并发使用
+type Client struct {
+	opts ClientOptions
+
+	client   *http.Client
+	fallback *http.Client // 握手失败时改用常用密码套件重试
+
+	limiter    *RateLimiter // 全局限速，nil 表示不限制
+	hostLimits *hostLimits  // 每IP限制，nil 表示不限制
+}
+
+// defaultCipherSuites 默认密码套件，包含老旧服务器使用的弱套件以提高兼容性
+var defaultCipherSuites = []uint16{
+	tls.TLS_RSA_WITH_RC4_128_SHA,
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_AES_128_GCM_SHA256,
+	tls.TLS_AES_256_GCM_SHA384,
+	tls.TLS_CHACHA20_POLY1305_SHA256,
+	tls.TLS_FALLBACK_SCSV,
+}
+
+// fallbackCipherSuites 重试时使用的常用密码套件
+var fallbackCipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,   // 常用且支持较好的前向保密
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,   // 较高安全性，性能稍低
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, // 更快的ECDSA验证
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, // 高安全性支持
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,    // 高效并适合低性能设备
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,  // 高效且兼容移动设备
+}
+
+// DefaultTLSConfig 默认TLS配置，不校验证书并支持 TLS1.0 - TLS1.3
+func DefaultTLSConfig() *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		MaxVersion:         tls.VersionTLS13,
+		CipherSuites:       defaultCipherSuites,
+	}
+}
+
+// NewClient 根据配置构建客户端
+func NewClient(opts ClientOptions) (*Client, error) {
+	var proxy func(*http.Request) (*url.URL, error)
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsconfig := opts.TLSConfig
+	if tlsconfig == nil {
+		tlsconfig = DefaultTLSConfig()
+	}
+	fallbackConfig := tlsconfig.Clone()
+	fallbackConfig.CipherSuites = fallbackCipherSuites
+
+	c := &Client{
+		opts:       opts,
+		limiter:    NewRateLimiter(opts.Rate, 1),
+		hostLimits: newHostLimits(opts.PerHostRate, opts.PerHostConcurrency, opts.Proxy != ""),
+	}
+	c.client = &http.Client{
+		Transport:     c.newTransport(proxy, tlsconfig),
+		Timeout:       opts.Timeout,
+		CheckRedirect: noRedirect,
+	}
+	c.fallback = &http.Client{
+		Transport:     c.newTransport(proxy, fallbackConfig),
+		Timeout:       opts.Timeout,
+		CheckRedirect: noRedirect,
+	}
+	return c, nil
+}
+
+// noRedirect 跳转由 SendRequest 手动跟随，以便按策略过滤并记录跳转链
+func noRedirect(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// newTransport 构建带连接池限制的 Transport
+func (c *Client) newTransport(proxy func(*http.Request) (*url.URL, error), tlsconfig *tls.Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   c.opts.Timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsconfig,
+		TLSHandshakeTimeout: c.opts.Timeout,
+		MaxConnsPerHost:     c.opts.MaxConnsPerHost,
+		MaxIdleConns:        c.opts.MaxIdleConns,
+		MaxIdleConnsPerHost: c.opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     30 * time.Second,
+		// 设置了 TLSClientConfig 时 Transport 不会自动启用 HTTP/2
+		ForceAttemptHTTP2: c.opts.HTTP2,
+	}
+}
+
+// userAgent 根据配置返回本次请求使用的 User-Agent
+func (c *Client) userAgent() string {
+	if c.opts.UserAgent != "" {
+		return c.opts.UserAgent
+	}
+	return getRandomUserAgent()
+}
+
+// JARMEnabled 是否需要计算 JARM 指纹
+func (c *Client) JARMEnabled() bool {
+	return c.opts.JARM
+}
+
+// CloseIdleConnections 关闭连接池中的空闲连接
+func (c *Client) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+	c.fallback.CloseIdleConnections()
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package httpgo
+
+import "syscall"
+
+// 连接被拒绝、重置时的系统错误码
+var (
+	refusedErrnos = []error{syscall.ECONNREFUSED}
+	resetErrnos   = []error{syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE}
+)
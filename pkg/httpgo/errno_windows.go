@@ -0,0 +1,11 @@
+//go:build windows
+
+package httpgo
+
+import "syscall"
+
+// Windows 上连接被拒绝、重置时返回 WSA 错误码，syscall.ECONNREFUSED 等不会匹配
+var (
+	refusedErrnos = []error{syscall.ECONNREFUSED, syscall.Errno(10061)} // WSAECONNREFUSED
+	resetErrnos   = []error{syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE, syscall.WSAECONNRESET, syscall.WSAECONNABORTED}
+)
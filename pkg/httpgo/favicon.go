@@ -1,11 +1,10 @@
 package httpgo
 
 import (
-	"fmt"
+	"context"
 	"httpgo/pkg/utils"
 	"net/url"
 	"strings"
-	"time"
 )
 
 type FaviconList struct {
@@ -14,11 +13,17 @@ type FaviconList struct {
 	FaviconHash []string
 }
 
-func (r *Response) GetFaviconHash(proxyStr string, timeoutInt time.Duration) (*FaviconList, error) {
+// GetFaviconHash 使用客户端 c 请求页面中的全部favicon并计算hash
+func (r *Response) GetFaviconHash(ctx context.Context, c *Client) (*FaviconList, error) {
 	var favicons []string
 	var faviconhash []string
 
-	u, err := url.Parse(r.Url)
+	// 跟随跳转后以最终页面为基准解析favicon地址
+	pageURL := r.Url
+	if r.FinalUrl != "" {
+		pageURL = r.FinalUrl
+	}
+	u, err := url.Parse(pageURL)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +52,7 @@ func (r *Response) GetFaviconHash(proxyStr string, timeoutInt time.Duration) (*F
 	favicons = RemoveDuplicates(favicons)
 
 	for i := range favicons {
-		fh, err := GetResponse(favicons[i], proxyStr, timeoutInt)
+		fh, err := c.GetResponse(ctx, favicons[i])
 		if err != nil {
 			return nil, err
 		}
@@ -68,13 +73,11 @@ func (r *Response) GetFaviconHash(proxyStr string, timeoutInt time.Duration) (*F
 func ResolveURL(baseURL string, href string) (string, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
-		fmt.Println(err)
 		return "", err
 	}
 
 	u, err := url.Parse(href)
 	if err != nil {
-		fmt.Println(err)
 		return "", err
 	}
 
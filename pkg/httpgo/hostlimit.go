@@ -0,0 +1,137 @@
+package httpgo
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// hostLimits 按目标解析后的IP限制请求速率与并发数，多个虚拟主机指向同一IP时共用限制
+type hostLimits struct {
+	rate        float64
+	concurrency int
+	byName      bool // 配置了代理时由代理解析主机名，按主机名限制，不在本地发起DNS查询
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+
+	resolved sync.Map // 主机名 -> IP（解析失败时为主机名本身），扫描期间缓存
+	lookup   func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type hostLimit struct {
+	limiter *RateLimiter
+	sem     chan struct{} // 为 nil 时不限制并发
+}
+
+// newHostLimits 创建每主机限制，rate 与 concurrency 都为0时返回 nil。
+// byName 为true时按主机名而不是解析后的IP限制
+func newHostLimits(rate float64, concurrency int, byName bool) *hostLimits {
+	if rate <= 0 && concurrency <= 0 {
+		return nil
+	}
+	return &hostLimits{
+		rate:        rate,
+		concurrency: concurrency,
+		byName:      byName,
+		hosts:       make(map[string]*hostLimit),
+		lookup:      net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+// key 将主机名解析为IP作为限制的键，解析失败时使用主机名，
+// 成功与失败的结果都在扫描期间缓存，避免对无法解析的主机重复查询
+func (h *hostLimits) key(ctx context.Context, host string) string {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	if h.byName {
+		return host
+	}
+	if ip, ok := h.resolved.Load(host); ok {
+		return ip.(string)
+	}
+	addrs, err := h.lookup(ctx, host)
+	if ctx.Err() != nil {
+		// 请求被取消导致的失败不缓存
+		return host
+	}
+	key := host
+	if err == nil && len(addrs) > 0 {
+		key = addrs[0].IP.String()
+	}
+	h.resolved.Store(host, key)
+	return key
+}
+
+func (h *hostLimits) get(key string) *hostLimit {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l, ok := h.hosts[key]
+	if !ok {
+		l = &hostLimit{limiter: NewRateLimiter(h.rate, 1)}
+		if h.concurrency > 0 {
+			l.sem = make(chan struct{}, h.concurrency)
+		}
+		h.hosts[key] = l
+	}
+	return l
+}
+
+// acquire 等待目标主机的并发名额与速率令牌，返回释放并发名额的函数
+func (h *hostLimits) acquire(ctx context.Context, host string) (func(), error) {
+	l := h.get(h.key(ctx, host))
+	release := func() {}
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-l.sem }) }
+	}
+	if err := l.limiter.Wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// releaseBody 关闭响应body时释放并发名额
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// send 在全局与每主机限制下发送请求，favicon、路径探测、跳转等所有请求都经过这里
+func (c *Client) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if c.hostLimits == nil {
+		return client.Do(req)
+	}
+
+	release, err := c.hostLimits.acquire(ctx, req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// 读取完响应内容后才释放并发名额
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
@@ -0,0 +1,113 @@
+package httpgo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimitsKey(t *testing.T) {
+	var lookups int32
+	h := newHostLimits(0, 1, false)
+	h.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		atomic.AddInt32(&lookups, 1)
+		switch host {
+		case "a.example", "b.example":
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.1")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	ctx := context.Background()
+
+	// 指向同一IP的虚拟主机共用限制
+	if a, b := h.key(ctx, "a.example"), h.key(ctx, "b.example"); a != "10.0.0.1" || b != a {
+		t.Errorf("got %s %s, want 10.0.0.1", a, b)
+	}
+	if k := h.key(ctx, "::1"); k != "::1" {
+		t.Errorf("ip literal: got %s", k)
+	}
+	// 解析失败时按主机名限制，失败结果也被缓存
+	for i := 0; i < 3; i++ {
+		if k := h.key(ctx, "bad.example"); k != "bad.example" {
+			t.Errorf("unresolvable host: got %s", k)
+		}
+		h.key(ctx, "a.example")
+	}
+	if n := atomic.LoadInt32(&lookups); n != 3 {
+		t.Errorf("got %d lookups, want 3", n)
+	}
+
+	// 配置了代理时不在本地解析
+	p := newHostLimits(0, 1, true)
+	p.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		t.Errorf("lookup %s with a proxy configured", host)
+		return nil, nil
+	}
+	if k := p.key(ctx, "a.example"); k != "a.example" {
+		t.Errorf("proxy: got %s, want the host name", k)
+	}
+}
+
+func TestHostLimitsConcurrency(t *testing.T) {
+	const limit = 2
+	var cur, max int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&cur, 1)
+		defer atomic.AddInt32(&cur, -1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, PerHostConcurrency: limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.SendRequest(context.Background(), "GET", srv.URL, nil, ""); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if m := atomic.LoadInt32(&max); m != limit {
+		t.Errorf("got at most %d concurrent requests, want %d", m, limit)
+	}
+
+	// 等待名额时 ctx 取消立即返回
+	h := newHostLimits(0, 1, false)
+	release, err := h.acquire(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := h.acquire(ctx, "127.0.0.1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want deadline exceeded", err)
+	}
+	release()
+	release() // 重复释放不能多归还名额
+	if _, err := h.acquire(context.Background(), "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := h.acquire(ctx, "127.0.0.1"); err == nil {
+		t.Error("double release freed an extra slot")
+	}
+}
@@ -1,14 +1,14 @@
 package httpgo
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"httpgo/pkg/utils"
+	"io"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"net/http"
-	"net/url"
 	"strings"
 	"time"
 )
@@ -20,93 +20,66 @@ type Response struct {
 	Body       []byte
 	HeadersMap map[string][]string
 	HeadersStr string
-	Cert       string // 添加证书字段
-}
+	Cert       string   // 添加证书字段
+	TLS        *TLSInfo // TLS 连接与证书信息，明文HTTP时为 nil
+	Protocol   string   // 使用的协议：http/1.0、http/1.1、h2
+	AltSvc     []string // Alt-Svc 响应头通告的协议，如 h3、h3-29
 
-func GetResponse(urlStr string, proxyStr string, timeoutInt time.Duration) (*Response, error) {
+	FinalUrl  string        // 跟随跳转后的最终地址，即响应内容所在的地址
+	Redirects []RedirectHop // 跳转链，不包含最终地址；最后一跳可能是检测到但未跟随的 HTML 跳转
 
-	var httpClient *http.Client
-	tlsconfig := &tls.Config{
-		InsecureSkipVerify: true,
-		MinVersion:         tls.VersionTLS10,
-		MaxVersion:         tls.VersionTLS13,
-		CipherSuites: []uint16{
-			tls.TLS_RSA_WITH_RC4_128_SHA,
-			tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
-			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
-			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-			tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
-			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
-			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
-			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
-			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
-			tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
-			tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
-			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
-			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
-			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
-			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
-			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
-			tls.TLS_AES_128_GCM_SHA256,
-			tls.TLS_AES_256_GCM_SHA384,
-			tls.TLS_CHACHA20_POLY1305_SHA256,
-			tls.TLS_FALLBACK_SCSV,
-		},
-	}
+	ErrorClass string // 请求失败时的错误类型，如 dns、refused、timeout、tls、reset
+	Error      string // 请求失败的原因
 
-	if proxyStr != "" {
-		proxyURL, err := url.Parse(proxyStr)
-		if err != nil {
-			log.Println("Error parsing proxy URL:", err)
-			return nil, err
-		}
+	Timing Timing // 最终地址请求的各阶段耗时，Total 包含跳转
+}
 
-		httpClient = &http.Client{
-			Transport: &http.Transport{
-				Proxy:           http.ProxyURL(proxyURL),
-				TLSClientConfig: tlsconfig,
-			},
-			Timeout: timeoutInt * time.Second,
-		}
-	} else {
-		httpClient = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: tlsconfig,
-			},
-			Timeout: timeoutInt * time.Second,
-		}
-	}
+// GetResponse 使用一次性客户端请求url，批量扫描时应使用 Client.GetResponse 以复用连接
+func GetResponse(urlStr string, proxyStr string, timeoutInt time.Duration) (*Response, error) {
+	return SendRequest("GET", urlStr, nil, "", proxyStr, timeoutInt)
+}
 
-	req, err := http.NewRequest("GET", urlStr, nil)
+// SendRequest 使用一次性客户端发送请求，批量扫描时应使用 Client.SendRequest 以复用连接
+func SendRequest(method string, urlStr string, reqHeaders map[string]string, reqBody string, proxyStr string, timeoutInt time.Duration) (*Response, error) {
+	c, err := NewClient(ClientOptions{Proxy: proxyStr, Timeout: timeoutInt * time.Second})
 	if err != nil {
-		log.Println("Error creating HTTP request:", err)
+		log.Println("Error parsing proxy URL:", err)
 		return nil, err
 	}
+	defer c.CloseIdleConnections()
+	return c.SendRequest(context.Background(), method, urlStr, reqHeaders, reqBody)
+}
 
-	// 设置自定义header请求头
-	req.Header.Set("User-Agent", getRandomUserAgent())
-	req.Header.Set("Referer", urlStr)
+// GetResponse 发送GET请求，ctx 取消时请求立即结束
+func (c *Client) GetResponse(ctx context.Context, urlStr string) (*Response, error) {
+	return c.SendRequest(ctx, "GET", urlStr, nil, "")
+}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		tlsconfig.CipherSuites = []uint16{
-			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,   // 常用且支持较好的前向保密
-			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,   // 较高安全性，性能稍低
-			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, // 更快的ECDSA验证
-			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, // 高安全性支持
-			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,    // 高效并适合低性能设备
-			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,  // 高效且兼容移动设备
-		}
+// GetPage 请求目标的首页。除 HTTP 跳转外还识别页面中的 meta refresh 和 JavaScript 跳转，
+// 开启 FollowHTMLRedirects 时跟随，否则只记录在跳转链中。favicon、路径探测等请求不识别 HTML 跳转
+func (c *Client) GetPage(ctx context.Context, urlStr string) (*Response, error) {
+	return c.sendRequest(ctx, "GET", urlStr, nil, "", true)
+}
+
+// SendRequest 发送自定义方法、请求头和请求体的请求，按客户端的跳转策略跟随 HTTP 跳转并记录跳转链
+func (c *Client) SendRequest(ctx context.Context, method string, urlStr string, reqHeaders map[string]string, reqBody string) (*Response, error) {
+	return c.sendRequest(ctx, method, urlStr, reqHeaders, reqBody, false)
+}
+
+// sendRequest 发送请求并跟随跳转，htmlRedirects 为true时同时处理页面中的 HTML 跳转
+func (c *Client) sendRequest(ctx context.Context, method string, urlStr string, reqHeaders map[string]string, reqBody string, htmlRedirects bool) (*Response, error) {
+	var hops []RedirectHop
+	current := urlStr
+	start := time.Now()
 
-		// Retry the request
-		resp, err = httpClient.Do(req)
+	for {
+		tr := &tracer{}
+		resp, err := c.do(tr.withTrace(ctx), method, current, urlStr, reqHeaders, reqBody)
 		if err != nil {
+			if _, ok := err.(*requestError); ok {
+				log.Println("Error creating HTTP request:", err)
+				return nil, err
+			}
 			//log.Println("Error making HTTP request after retry:", err)
 			return &Response{
 				Url:        urlStr,
@@ -116,9 +89,131 @@ func GetResponse(urlStr string, proxyStr string, timeoutInt time.Duration) (*Res
 				HeadersMap: nil,
 				HeadersStr: "",
 				Cert:       "",
+				FinalUrl:   current,
+				Redirects:  hops,
+				ErrorClass: ClassifyError(err),
+				Error:      err.Error(),
+				Timing:     tr.timing(time.Since(start)),
 			}, nil
 		}
+
+		// HTTP 3xx 跳转
+		if location := resp.Header.Get("Location"); location != "" && isRedirectStatus(resp.StatusCode) {
+			if next, ok := c.nextURL(urlStr, current, location, len(hops)); ok {
+				hops = append(hops, RedirectHop{Url: current, StatusCode: resp.StatusCode, Location: location, Kind: RedirectHTTP})
+				io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+				resp.Body.Close()
+				method, reqBody = redirectMethod(method, reqBody, resp.StatusCode)
+				current = next
+				continue
+			}
+		}
+
+		r, err := readResponse(resp, urlStr)
+		if err != nil {
+			return nil, err
+		}
+		r.FinalUrl = current
+		r.Redirects = hops
+		r.Timing = tr.timing(time.Since(start))
+
+		// meta refresh 与 JavaScript 跳转，只在请求首页时处理
+		if !htmlRedirects {
+			return r, nil
+		}
+		if location, kind := utils.ExtractHTMLRedirect(r.Body); location != "" {
+			if c.opts.FollowHTMLRedirects {
+				if next, ok := c.nextURL(urlStr, current, location, len(hops)); ok && next != current {
+					hops = append(hops, RedirectHop{Url: current, StatusCode: r.StatusCode, Location: location, Kind: kind})
+					method, reqBody = "GET", ""
+					current = next
+					continue
+				}
+			} else if next, ok := resolveLocation(current, location); ok && next.String() != current {
+				// 未开启跟随时只在跳转链中记录检测到的跳转，不发送请求，最终地址仍为当前页面
+				r.Redirects = append(hops, RedirectHop{Url: current, StatusCode: r.StatusCode, Location: location, Kind: kind, NotFollowed: true})
+			}
+		}
+
+		return r, nil
+	}
+}
+
+// requestError 请求构建失败（如url格式错误），与网络错误区分
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// do 发送单个请求，不跟随跳转；GET/HEAD 请求超时、连接重置或 5xx 时按重试策略重试
+func (c *Client) do(ctx context.Context, method string, urlStr string, referer string, reqHeaders map[string]string, reqBody string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, method, urlStr, referer, reqHeaders, reqBody)
+		if _, ok := err.(*requestError); ok {
+			return nil, err
+		}
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		if attempt >= c.opts.Retry.Retries || ctx.Err() != nil || !retryable(method, statusCode, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		if err := c.opts.Retry.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt 发送一次请求；TLS 握手失败时改用常用密码套件重试一次。
+// 握手失败时请求尚未发出，任何方法都可以安全重试
+func (c *Client) attempt(ctx context.Context, method string, urlStr string, referer string, reqHeaders map[string]string, reqBody string) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if reqBody != "" {
+			bodyReader = strings.NewReader(reqBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
+		if err != nil {
+			return nil, &requestError{err}
+		}
+
+		// 设置自定义header请求头
+		req.Header.Set("User-Agent", c.userAgent())
+		req.Header.Set("Referer", referer)
+		for k, v := range reqHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(c.client, req)
+	if err != nil && ctx.Err() == nil && ClassifyError(err) == ErrorTLS {
+		// 改用常用密码套件重试
+		req, err = newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.send(c.fallback, req)
 	}
+	return resp, err
+}
+
+// readResponse 读取响应内容并关闭body
+func readResponse(resp *http.Response, urlStr string) (*Response, error) {
 	defer resp.Body.Close()
 
 	// 获取证书信息
@@ -176,6 +271,9 @@ func GetResponse(urlStr string, proxyStr string, timeoutInt time.Duration) (*Res
 		HeadersMap: headers,
 		HeadersStr: headersstr,
 		Cert:       certInfo.String(),
+		TLS:        newTLSInfo(resp.TLS),
+		Protocol:   protocolName(resp),
+		AltSvc:     ParseAltSvc(resp.Header.Values("Alt-Svc")),
 	}, nil
 }
 
@@ -0,0 +1,425 @@
+package httpgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// JARM 服务器的 JARM 指纹（https://github.com/salesforce/jarm），
+// 向 addr（host:port）依次发送10个构造的 ClientHello，由服务器选择的密码套件、版本和扩展计算得到。
+// 服务器未响应任何探测时返回 JARMZero。
+func (c *Client) JARM(ctx context.Context, addr string) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+
+	answers := make([]string, 0, len(jarmProbes))
+	for _, p := range jarmProbes {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		answer, err := c.jarmProbe(ctx, addr, host, p)
+		if err != nil {
+			// 第一个探测就无法连接时目标不可达，之后的连接失败按未响应处理
+			if len(answers) == 0 {
+				return "", err
+			}
+			answer = "|||"
+		}
+		answers = append(answers, answer)
+	}
+	return jarmHash(answers), nil
+}
+
+// JARMZero 服务器未响应任何探测时的 JARM 指纹
+const JARMZero = "00000000000000000000000000000000000000000000000000000000000000"
+
+// jarmOptions 单个探测的 ClientHello 参数，与 JARM 的参考实现一致
+type jarmOptions struct {
+	version    uint16 // ClientHello 中的版本
+	ciphers    string // ALL 或 NO1.3
+	order      string // 密码套件顺序：FORWARD、REVERSE、TOP_HALF、BOTTOM_HALF、MIDDLE_OUT
+	grease     bool
+	alpn       string // ALPN 或 RARE_ALPN
+	v13        string // 1.2_SUPPORT、1.3_SUPPORT 或 NO_SUPPORT
+	extensions string // ALPN 与 supported_versions 的顺序：FORWARD 或 REVERSE
+}
+
+var jarmProbes = []jarmOptions{
+	{0x0303, "ALL", "FORWARD", false, "ALPN", "1.2_SUPPORT", "REVERSE"},
+	{0x0303, "ALL", "REVERSE", false, "ALPN", "1.2_SUPPORT", "FORWARD"},
+	{0x0303, "ALL", "TOP_HALF", false, "NO_SUPPORT", "NO_SUPPORT", "FORWARD"},
+	{0x0303, "ALL", "BOTTOM_HALF", false, "RARE_ALPN", "NO_SUPPORT", "FORWARD"},
+	{0x0303, "ALL", "MIDDLE_OUT", true, "RARE_ALPN", "NO_SUPPORT", "REVERSE"},
+	{0x0302, "ALL", "FORWARD", false, "ALPN", "NO_SUPPORT", "FORWARD"},
+	{0x0304, "ALL", "FORWARD", false, "ALPN", "1.3_SUPPORT", "REVERSE"},
+	{0x0304, "ALL", "REVERSE", false, "ALPN", "1.3_SUPPORT", "FORWARD"},
+	{0x0304, "NO1.3", "FORWARD", false, "ALPN", "1.3_SUPPORT", "FORWARD"},
+	{0x0304, "ALL", "MIDDLE_OUT", true, "ALPN", "1.3_SUPPORT", "REVERSE"},
+}
+
+// jarmProbe 发送一个 ClientHello，返回 "密码套件|版本|ALPN|扩展列表"，服务器拒绝握手时返回 "|||"
+func (c *Client) jarmProbe(ctx context.Context, addr, host string, p jarmOptions) (string, error) {
+	conn, err := c.dialTCP(ctx, addr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	timeout := c.opts.Timeout
+	if timeout <= 0 {
+		timeout = 8 * time.Second
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(jarmClientHello(host, p)); err != nil {
+		return "|||", nil
+	}
+	// 与参考实现相同，只读取一次最多 1484 字节
+	buf := make([]byte, 1484)
+	n, err := conn.Read(buf)
+	if err != nil && err != io.EOF {
+		return "|||", nil
+	}
+	return parseServerHello(buf[:n]), nil
+}
+
+// jarmCiphers 探测使用的密码套件
+var jarmCiphers = []uint16{
+	0x0016, 0x0033, 0x0067, 0xc09e, 0xc0a2, 0x009e, 0x0039, 0x006b,
+	0xc09f, 0xc0a3, 0x009f, 0x0045, 0x00be, 0x0088, 0x00c4, 0x009a,
+	0xc008, 0xc009, 0xc023, 0xc0ac, 0xc0ae, 0xc02b, 0xc00a, 0xc024,
+	0xc0ad, 0xc0af, 0xc02c, 0xc072, 0xc073, 0xcca9, 0x1302, 0x1301,
+	0xcc14, 0xc007, 0xc012, 0xc013, 0xc027, 0xc02f, 0xc014, 0xc028,
+	0xc030, 0xc060, 0xc061, 0xc076, 0xc077, 0xcca8, 0x1305, 0x1304,
+	0x1303, 0xcc13, 0xc011, 0x000a, 0x002f, 0x003c, 0xc09c, 0xc0a0,
+	0x009c, 0x0035, 0x003d, 0xc09d, 0xc0a1, 0x009d, 0x0041, 0x00ba,
+	0x0084, 0x00c0, 0x0007, 0x0004, 0x0005,
+}
+
+// jarmCipherOrder 计算指纹时密码套件的编号顺序
+var jarmCipherOrder = []uint16{
+	0x0004, 0x0005, 0x0007, 0x000a, 0x0016, 0x002f, 0x0033, 0x0035,
+	0x0039, 0x003c, 0x003d, 0x0041, 0x0045, 0x0067, 0x006b, 0x0084,
+	0x0088, 0x009a, 0x009c, 0x009d, 0x009e, 0x009f, 0x00ba, 0x00be,
+	0x00c0, 0x00c4, 0xc007, 0xc008, 0xc009, 0xc00a, 0xc011, 0xc012,
+	0xc013, 0xc014, 0xc023, 0xc024, 0xc027, 0xc028, 0xc02b, 0xc02c,
+	0xc02f, 0xc030, 0xc060, 0xc061, 0xc072, 0xc073, 0xc076, 0xc077,
+	0xc09c, 0xc09d, 0xc09e, 0xc09f, 0xc0a0, 0xc0a1, 0xc0a2, 0xc0a3,
+	0xc0ac, 0xc0ad, 0xc0ae, 0xc0af, 0xcc13, 0xcc14, 0xcca8, 0xcca9,
+	0x1301, 0x1302, 0x1303, 0x1304, 0x1305,
+}
+
+// jarmALPNs 由弱到强的 ALPN 列表，RARE_ALPN 不包含 http/1.1 和 h2
+var (
+	jarmALPNs     = []string{"http/0.9", "http/1.0", "http/1.1", "spdy/1", "spdy/2", "spdy/3", "h2", "h2c", "hq"}
+	jarmRareALPNs = []string{"http/0.9", "http/1.0", "spdy/1", "spdy/2", "spdy/3", "h2c", "hq"}
+)
+
+// jarmClientHello 构造完整的 TLS 记录
+func jarmClientHello(host string, p jarmOptions) []byte {
+	recordVersion := p.version
+	if p.version == 0x0304 {
+		// TLS1.3 的 ClientHello 使用 TLS1.2 的版本号，记录层使用 TLS1.0
+		recordVersion = 0x0301
+	}
+	helloVersion := p.version
+	if p.version == 0x0304 {
+		helloVersion = 0x0303
+	}
+
+	var hello []byte
+	hello = appendUint16(hello, helloVersion)
+	hello = append(hello, randomBytes(32)...)
+	hello = append(hello, 32)
+	hello = append(hello, randomBytes(32)...)
+
+	ciphers := jarmCipherList(p)
+	hello = appendUint16(hello, uint16(len(ciphers)))
+	hello = append(hello, ciphers...)
+	hello = append(hello, 0x01, 0x00) // 不压缩
+	hello = append(hello, jarmExtensions(host, p)...)
+
+	handshake := []byte{0x01, 0x00}
+	handshake = appendUint16(handshake, uint16(len(hello)))
+	handshake = append(handshake, hello...)
+
+	record := []byte{0x16}
+	record = appendUint16(record, recordVersion)
+	record = appendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...)
+}
+
+// jarmCipherList 按探测参数排列密码套件
+func jarmCipherList(p jarmOptions) []byte {
+	var ciphers [][]byte
+	for _, c := range jarmCiphers {
+		// NO1.3 不包含 TLS1.3 的密码套件
+		if p.ciphers == "NO1.3" && c>>8 == 0x13 {
+			continue
+		}
+		ciphers = append(ciphers, appendUint16(nil, c))
+	}
+	ciphers = jarmReorder(ciphers, p.order)
+	if p.grease {
+		ciphers = append([][]byte{randomGrease()}, ciphers...)
+	}
+	return bytes.Join(ciphers, nil)
+}
+
+// jarmReorder 按 order 重新排列列表
+func jarmReorder(items [][]byte, order string) [][]byte {
+	n := len(items)
+	switch order {
+	case "REVERSE":
+		out := make([][]byte, 0, n)
+		for i := n - 1; i >= 0; i-- {
+			out = append(out, items[i])
+		}
+		return out
+	case "BOTTOM_HALF":
+		if n%2 == 1 {
+			return items[n/2+1:]
+		}
+		return items[n/2:]
+	case "TOP_HALF":
+		var out [][]byte
+		if n%2 == 1 {
+			out = append(out, items[n/2])
+		}
+		return append(out, jarmReorder(jarmReorder(items, "REVERSE"), "BOTTOM_HALF")...)
+	case "MIDDLE_OUT":
+		middle := n / 2
+		var out [][]byte
+		if n%2 == 1 {
+			out = append(out, items[middle])
+			for i := 1; i <= middle; i++ {
+				out = append(out, items[middle+i], items[middle-i])
+			}
+		} else {
+			for i := 1; i <= middle; i++ {
+				out = append(out, items[middle-1+i], items[middle-i])
+			}
+		}
+		return out
+	}
+	return items
+}
+
+// jarmExtensions 构造扩展列表（含长度前缀）
+func jarmExtensions(host string, p jarmOptions) []byte {
+	var ext []byte
+	if p.grease {
+		ext = append(ext, randomGrease()...)
+		ext = append(ext, 0x00, 0x00)
+	}
+
+	// server_name
+	ext = append(ext, 0x00, 0x00)
+	ext = appendUint16(ext, uint16(len(host)+5))
+	ext = appendUint16(ext, uint16(len(host)+3))
+	ext = append(ext, 0x00)
+	ext = appendUint16(ext, uint16(len(host)))
+	ext = append(ext, host...)
+
+	// extended_master_secret
+	ext = append(ext, 0x00, 0x17, 0x00, 0x00)
+	// max_fragment_length
+	ext = append(ext, 0x00, 0x01, 0x00, 0x01, 0x01)
+	// renegotiation_info
+	ext = append(ext, 0xff, 0x01, 0x00, 0x01, 0x00)
+	// supported_groups
+	ext = append(ext, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x08, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19)
+	// ec_point_formats
+	ext = append(ext, 0x00, 0x0b, 0x00, 0x02, 0x01, 0x00)
+	// session_ticket
+	ext = append(ext, 0x00, 0x23, 0x00, 0x00)
+	ext = append(ext, jarmALPN(p)...)
+	// signature_algorithms
+	ext = append(ext, 0x00, 0x0d, 0x00, 0x14, 0x00, 0x12, 0x04, 0x03, 0x08, 0x04, 0x04, 0x01,
+		0x05, 0x03, 0x08, 0x05, 0x05, 0x01, 0x08, 0x06, 0x06, 0x01, 0x02, 0x01)
+	ext = append(ext, jarmKeyShare(p.grease)...)
+	// psk_key_exchange_modes
+	ext = append(ext, 0x00, 0x2d, 0x00, 0x02, 0x01, 0x01)
+	if p.version == 0x0304 || p.v13 == "1.2_SUPPORT" {
+		ext = append(ext, jarmSupportedVersions(p)...)
+	}
+
+	return append(appendUint16(nil, uint16(len(ext))), ext...)
+}
+
+// jarmALPN application_layer_protocol_negotiation 扩展
+func jarmALPN(p jarmOptions) []byte {
+	names := jarmALPNs
+	if p.alpn == "RARE_ALPN" {
+		names = jarmRareALPNs
+	}
+	var protos [][]byte
+	for _, name := range names {
+		protos = append(protos, append([]byte{byte(len(name))}, name...))
+	}
+	list := bytes.Join(jarmReorder(protos, p.extensions), nil)
+
+	ext := []byte{0x00, 0x10}
+	ext = appendUint16(ext, uint16(len(list)+2))
+	ext = appendUint16(ext, uint16(len(list)))
+	return append(ext, list...)
+}
+
+// jarmKeyShare key_share 扩展，使用随机的 x25519 公钥
+func jarmKeyShare(grease bool) []byte {
+	var share []byte
+	if grease {
+		share = append(share, randomGrease()...)
+		share = append(share, 0x00, 0x01, 0x00)
+	}
+	share = append(share, 0x00, 0x1d, 0x00, 0x20)
+	share = append(share, randomBytes(32)...)
+
+	ext := []byte{0x00, 0x33}
+	ext = appendUint16(ext, uint16(len(share)+2))
+	ext = appendUint16(ext, uint16(len(share)))
+	return append(ext, share...)
+}
+
+// jarmSupportedVersions supported_versions 扩展
+func jarmSupportedVersions(p jarmOptions) []byte {
+	versions := [][]byte{{0x03, 0x01}, {0x03, 0x02}, {0x03, 0x03}}
+	if p.v13 != "1.2_SUPPORT" {
+		versions = append(versions, []byte{0x03, 0x04})
+	}
+	var list []byte
+	if p.grease {
+		list = append(list, randomGrease()...)
+	}
+	list = append(list, bytes.Join(jarmReorder(versions, p.extensions), nil)...)
+
+	ext := []byte{0x00, 0x2b}
+	ext = appendUint16(ext, uint16(len(list)+1))
+	ext = append(ext, byte(len(list)))
+	return append(ext, list...)
+}
+
+// parseServerHello 解析服务器的响应，与参考实现一样按固定偏移读取
+func parseServerHello(data []byte) string {
+	// 0x15 为 Alert，0x16 + 0x02 为 ServerHello
+	if len(data) < 44 || data[0] != 0x16 || data[5] != 0x02 {
+		return "|||"
+	}
+	helloLength := int(binary.BigEndian.Uint16(data[3:5]))
+	sessionLen := int(data[43])
+	cipherOffset := sessionLen + 44
+	if len(data) < cipherOffset+2 {
+		return "|||"
+	}
+	cipher := hex.EncodeToString(data[cipherOffset : cipherOffset+2])
+	version := hex.EncodeToString(data[9:11])
+	return cipher + "|" + version + "|" + jarmExtensionInfo(data, sessionLen, helloLength)
+}
+
+// jarmExtensionInfo 返回 "ALPN|扩展类型列表"
+func jarmExtensionInfo(data []byte, offset int, helloLength int) string {
+	if len(data) < 85 || len(data) < offset+53 {
+		return "|"
+	}
+	if data[offset+47] == 11 || offset+42 >= helloLength {
+		return "|"
+	}
+	if bytes.Equal(data[offset+50:offset+53], []byte{0x0e, 0xac, 0x0b}) || bytes.Equal(data[82:85], []byte{0x0f, 0xf0, 0x0b}) {
+		return "|"
+	}
+
+	count := offset + 49
+	end := int(binary.BigEndian.Uint16(data[offset+47:offset+49])) + count - 1
+	var types []string
+	alpn := ""
+	for count < end && len(data) >= count+4 {
+		typ := data[count : count+2]
+		length := int(binary.BigEndian.Uint16(data[count+2 : count+4]))
+		if len(data) < count+4+length {
+			break
+		}
+		value := data[count+4 : count+4+length]
+		if alpn == "" && typ[0] == 0x00 && typ[1] == 0x10 && len(value) >= 4 {
+			alpn = string(value[3:])
+		}
+		types = append(types, hex.EncodeToString(typ))
+		count += length + 4
+	}
+	return alpn + "|" + strings.Join(types, "-")
+}
+
+// jarmHash 由10个探测结果计算 JARM 指纹：前30位为每个探测选择的密码套件与版本，后32位为 ALPN 与扩展的 SHA-256
+func jarmHash(answers []string) string {
+	empty := true
+	for _, a := range answers {
+		if a != "|||" {
+			empty = false
+		}
+	}
+	if empty {
+		return JARMZero
+	}
+
+	var fuzzy, alpnExt strings.Builder
+	for _, a := range answers {
+		parts := strings.Split(a, "|")
+		if len(parts) != 4 {
+			return JARMZero
+		}
+		fuzzy.WriteString(jarmCipherIndex(parts[0]))
+		fuzzy.WriteString(jarmVersion(parts[1]))
+		alpnExt.WriteString(parts[2])
+		alpnExt.WriteString(parts[3])
+	}
+	sum := sha256.Sum256([]byte(alpnExt.String()))
+	return fuzzy.String() + hex.EncodeToString(sum[:])[:32]
+}
+
+// jarmCipherIndex 密码套件在 jarmCipherOrder 中的编号（从1开始，两位十六进制），未选择时为 00
+func jarmCipherIndex(cipher string) string {
+	if cipher == "" {
+		return "00"
+	}
+	i := 0
+	for ; i < len(jarmCipherOrder); i++ {
+		if fmt.Sprintf("%04x", jarmCipherOrder[i]) == cipher {
+			break
+		}
+	}
+	return fmt.Sprintf("%02x", i+1)
+}
+
+// jarmVersion 版本的最后一位映射为字母，如 0303 -> d
+func jarmVersion(version string) string {
+	if len(version) < 4 || version[3] < '0' || version[3] > '9' {
+		return "0"
+	}
+	return string(rune('a' + version[3] - '0'))
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// randomGrease 随机的 GREASE 值，如 0x1a1a
+func randomGrease() []byte {
+	b := randomBytes(1)
+	v := 0x0a + (b[0]%16)<<4
+	return []byte{v, v}
+}
@@ -0,0 +1,264 @@
+package httpgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJARM(t *testing.T) {
+	newServer := func(config *tls.Config) *httptest.Server {
+		s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		s.Config.ErrorLog = log.New(io.Discard, "", 0)
+		s.TLS = config
+		s.StartTLS()
+		t.Cleanup(s.Close)
+		return s
+	}
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	addr := newServer(nil).Listener.Addr().String()
+	first, err := c.JARM(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != len(JARMZero) || first == JARMZero {
+		t.Fatalf("JARM(%s) = %q", addr, first)
+	}
+	second, err := c.JARM(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("JARM is not stable: %s != %s", first, second)
+	}
+
+	// 只支持 TLS1.2 的服务器选择的版本不同，指纹也应不同
+	tls12, err := c.JARM(ctx, newServer(&tls.Config{MaxVersion: tls.VersionTLS12}).Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tls12 == first || tls12 == JARMZero {
+		t.Errorf("JARM of TLS1.2 server = %s, default server = %s", tls12, first)
+	}
+
+	if _, err := c.JARM(ctx, closedAddr(t)); err == nil {
+		t.Error("JARM of a closed port should fail")
+	}
+}
+
+// 以下期望值均由参考实现 github.com/hdm/jarm-go 对相同输入计算得到
+
+func TestJARMProbes(t *testing.T) {
+	want := []string{
+		"0303 69 0016-0005 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d-002b hq,h2c,h2,spdy/3,spdy/2,spdy/1,http/1.1,http/1.0,http/0.9 030303020301",
+		"0303 69 0005-0016 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d-002b http/0.9,http/1.0,http/1.1,spdy/1,spdy/2,spdy/3,h2,h2c,hq 030103020303",
+		"0303 35 c012-0016 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d http/0.9,http/1.0,http/1.1,spdy/1,spdy/2,spdy/3,h2,h2c,hq ",
+		"0303 34 c013-0005 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d http/0.9,http/1.0,spdy/1,spdy/2,spdy/3,h2c,hq ",
+		"0303 69 c012-0016 grease-0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d hq,h2c,spdy/3,spdy/2,spdy/1,http/1.0,http/0.9 ",
+		"0302 69 0016-0005 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d http/0.9,http/1.0,http/1.1,spdy/1,spdy/2,spdy/3,h2,h2c,hq ",
+		"0303 69 0016-0005 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d-002b hq,h2c,h2,spdy/3,spdy/2,spdy/1,http/1.1,http/1.0,http/0.9 0304030303020301",
+		"0303 69 0005-0016 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d-002b http/0.9,http/1.0,http/1.1,spdy/1,spdy/2,spdy/3,h2,h2c,hq 0301030203030304",
+		"0303 64 0016-0005 0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d-002b http/0.9,http/1.0,http/1.1,spdy/1,spdy/2,spdy/3,h2,h2c,hq 0301030203030304",
+		"0303 69 c012-0016 grease-0000-0017-0001-ff01-000a-000b-0023-0010-000d-0033-002d-002b hq,h2c,h2,spdy/3,spdy/2,spdy/1,http/1.1,http/1.0,http/0.9 0304030303020301",
+	}
+	if len(jarmProbes) != len(want) {
+		t.Fatalf("got %d probes, want %d", len(jarmProbes), len(want))
+	}
+	for i, p := range jarmProbes {
+		if got := helloSummary(jarmClientHello("example.com", p)); got != want[i] {
+			t.Errorf("probe %d:\n got %s\nwant %s", i, got, want[i])
+		}
+	}
+}
+
+func TestJARMReorder(t *testing.T) {
+	items := func(n int) [][]byte {
+		var out [][]byte
+		for i := 1; i <= n; i++ {
+			out = append(out, []byte{byte(i)})
+		}
+		return out
+	}
+	tests := []struct {
+		n     int
+		order string
+		want  []byte
+	}{
+		{5, "FORWARD", []byte{1, 2, 3, 4, 5}},
+		{5, "REVERSE", []byte{5, 4, 3, 2, 1}},
+		{5, "BOTTOM_HALF", []byte{4, 5}},
+		{6, "BOTTOM_HALF", []byte{4, 5, 6}},
+		{5, "TOP_HALF", []byte{3, 2, 1}},
+		{6, "TOP_HALF", []byte{3, 2, 1}},
+		{5, "MIDDLE_OUT", []byte{3, 4, 2, 5, 1}},
+		{6, "MIDDLE_OUT", []byte{4, 3, 5, 2, 6, 1}},
+	}
+	for _, tt := range tests {
+		if got := bytes.Join(jarmReorder(items(tt.n), tt.order), nil); !bytes.Equal(got, tt.want) {
+			t.Errorf("jarmReorder(%d, %s) = %v, want %v", tt.n, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestParseServerHello(t *testing.T) {
+	tls12 := serverHello(0x0303, 32, 0xc02f,
+		extension(0xff01, []byte{0x00}),
+		extension(0x000b, []byte{0x01, 0x00}),
+		extension(0x0023, nil),
+		extension(0x0010, []byte{0x00, 0x03, 0x02, 'h', '2'}),
+		extension(0x0017, nil))
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"tls1.2", tls12, "c02f|0303|h2|ff01-000b-0023-0010-0017"},
+		{"tls1.3", serverHello(0x0303, 32, 0x1301,
+			extension(0x002b, []byte{0x03, 0x04}),
+			extension(0x0033, append([]byte{0x00, 0x1d, 0x00, 0x20}, bytes.Repeat([]byte{1}, 32)...))),
+			"1301|0303||002b-0033"},
+		{"http/1.1", serverHello(0x0303, 32, 0x009c,
+			extension(0x0010, []byte{0x00, 0x09, 0x08, 'h', 't', 't', 'p', '/', '1', '.', '1'}),
+			extension(0xff01, []byte{0x00}),
+			extension(0x000b, []byte{0x01, 0x00}),
+			extension(0x0017, nil),
+			extension(0x0023, nil)),
+			"009c|0303|http/1.1|0010-ff01-000b-0017-0023"},
+		{"tls1.0", serverHello(0x0301, 32, 0xc014,
+			extension(0xff01, []byte{0x00}),
+			extension(0x000b, []byte{0x01, 0x00}),
+			extension(0x0023, nil)),
+			"c014|0301||ff01-000b-0023"},
+		{"no extensions", serverHello(0x0303, 32, 0x002f), "002f|0303||"},
+		{"short session id", serverHello(0x0303, 0, 0x009c, extension(0x0010, []byte{0x00, 0x03, 0x02, 'h', '2'})), "009c|0303||"},
+		{"alert", []byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28}, "|||"},
+		{"empty", nil, "|||"},
+		{"truncated", tls12[:40], "|||"},
+		{"not a handshake", append([]byte{0x17, 0x03, 0x03, 0x00, 0x02}, make([]byte, 60)...), "|||"},
+	}
+	for _, tt := range tests {
+		if got := parseServerHello(tt.data); got != tt.want {
+			t.Errorf("%s: parseServerHello = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJARMHash(t *testing.T) {
+	empty := strings.Split(strings.Repeat("|||,", 9)+"|||", ",")
+	tests := []struct {
+		answers []string
+		want    string
+	}{
+		{[]string{
+			"c02f|0303|h2|ff01-000b-0023-0010-0017", "c02f|0303|h2|ff01-000b-0023-0010-0017", "|||",
+			"c02f|0303||ff01-000b-0023-0017", "c02f|0303||ff01-000b-0023-0017", "c014|0302||ff01-000b-0023-0017",
+			"1301|0303|h2|002b-0033", "1301|0303|h2|002b-0033", "c02f|0303|h2|002b-0033", "1301|0303|h2|002b-0033",
+		}, "29d29d00029d29d22c41d41d29d41d2ed9c88a277a78f94302aad708978d81"},
+		{empty, JARMZero},
+		{append(append([]string{}, empty[:9]...), "0005|0304||"), "00000000000000000000000000002ee3b0c44298fc1c149afbf4c8996fb924"},
+		{append(append([]string{}, empty[:9]...), "bad"), JARMZero},
+	}
+	for _, tt := range tests {
+		if got := jarmHash(tt.answers); got != tt.want {
+			t.Errorf("jarmHash(%v) = %s, want %s", tt.answers, got, tt.want)
+		}
+	}
+}
+
+// serverHello 构造 ServerHello 记录，exts 为完整的扩展（类型、长度和内容）
+func serverHello(version uint16, sessionLen int, cipher uint16, exts ...[]byte) []byte {
+	var body []byte
+	body = appendUint16(body, version)
+	body = append(body, bytes.Repeat([]byte{0x5a}, 32)...)
+	body = append(body, byte(sessionLen))
+	body = append(body, bytes.Repeat([]byte{0xa5}, sessionLen)...)
+	body = appendUint16(body, cipher)
+	body = append(body, 0x00)
+	if len(exts) > 0 {
+		list := bytes.Join(exts, nil)
+		body = appendUint16(body, uint16(len(list)))
+		body = append(body, list...)
+	}
+
+	handshake := []byte{0x02, 0x00}
+	handshake = appendUint16(handshake, uint16(len(body)))
+	handshake = append(handshake, body...)
+
+	record := []byte{0x16, 0x03, 0x03}
+	record = appendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...)
+}
+
+// extension 构造一个扩展
+func extension(typ uint16, value []byte) []byte {
+	ext := appendUint16(nil, typ)
+	ext = appendUint16(ext, uint16(len(value)))
+	return append(ext, value...)
+}
+
+// isGrease 判断是否为 GREASE 值
+func isGrease(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// helloSummary 概括 ClientHello 中与随机数无关的部分：
+// 版本、密码套件数量及首尾、扩展类型顺序、ALPN 列表和 supported_versions
+func helloSummary(b []byte) string {
+	u16 := func(i int) uint16 { return binary.BigEndian.Uint16(b[i:]) }
+	version := u16(9)
+	i := 43
+	i += 1 + int(b[i])
+
+	var ciphers []uint16
+	end := i + 2 + int(u16(i))
+	for i += 2; i < end; i += 2 {
+		if !isGrease(u16(i)) {
+			ciphers = append(ciphers, u16(i))
+		}
+	}
+	i += 1 + int(b[i])
+
+	var types, alpn []string
+	versions := ""
+	end = i + 2 + int(u16(i))
+	for i += 2; i < end; {
+		typ, length := u16(i), int(u16(i+2))
+		value := b[i+4 : i+4+length]
+		switch {
+		case isGrease(typ):
+			types = append(types, "grease")
+		default:
+			types = append(types, fmt.Sprintf("%04x", typ))
+		}
+		switch typ {
+		case 0x0010:
+			for j := 2; j < len(value); j += 1 + int(value[j]) {
+				alpn = append(alpn, string(value[j+1:j+1+int(value[j])]))
+			}
+		case 0x002b:
+			for j := 1; j < len(value); j += 2 {
+				if v := binary.BigEndian.Uint16(value[j:]); !isGrease(v) {
+					versions += fmt.Sprintf("%04x", v)
+				}
+			}
+		}
+		i += 4 + length
+	}
+	return fmt.Sprintf("%04x %d %04x-%04x %s %s %s", version, len(ciphers), ciphers[0], ciphers[len(ciphers)-1],
+		strings.Join(types, "-"), strings.Join(alpn, ","), versions)
+}
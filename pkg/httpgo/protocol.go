@@ -0,0 +1,60 @@
+package httpgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// protocolName 返回响应使用的协议，与 ALPN 的名称一致：http/1.0、http/1.1、h2
+func protocolName(resp *http.Response) string {
+	if resp.ProtoMajor == 2 {
+		return "h2"
+	}
+	return strings.ToLower(resp.Proto)
+}
+
+// ParseAltSvc 解析 Alt-Svc 响应头，返回其中通告的协议（如 h3、h3-29），去重并保持顺序。
+// 值为 clear 时表示取消之前的通告，返回 nil
+func ParseAltSvc(values []string) []string {
+	var protocols []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, entry := range splitQuoted(value, ',') {
+			// 每一项的格式为 protocol="host:port"; ma=86400; persist=1
+			entry = strings.TrimSpace(entry)
+			if entry == "clear" {
+				return nil
+			}
+			// 只取第一个 ; 之前的 protocol="host:port"，其余为参数
+			name, _, ok := strings.Cut(splitQuoted(entry, ';')[0], "=")
+			if !ok {
+				continue
+			}
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			protocols = append(protocols, name)
+		}
+	}
+	return protocols
+}
+
+// splitQuoted 按 sep 分割，忽略双引号内的分隔符，如 h3="a,b:443"
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,72 @@
+package httpgo
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAltSvc(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"empty", nil, nil},
+		{"single", []string{`h3=":443"`}, []string{"h3"}},
+		{"parameters", []string{`h3=":443"; ma=86400; persist=1`}, []string{"h3"}},
+		{"multiple entries", []string{`h3=":443"; ma=86400, h3-29=":443"; ma=86400, h2="alt.example.com:443"`}, []string{"h3", "h3-29", "h2"}},
+		{"multiple headers", []string{`h3=":443"`, `h3-29=":8443"`}, []string{"h3", "h3-29"}},
+		{"duplicates", []string{`h3=":443", h3=":8443"`}, []string{"h3"}},
+		{"quoted comma", []string{`h3="a,b.example.com:443"; ma=60, h2=":443"`}, []string{"h3", "h2"}},
+		{"quoted escape", []string{`h3="a\",b:443", h2=":443"`}, []string{"h3", "h2"}},
+		{"spaces", []string{` h3 = ":443" ,  h2=":443" `}, []string{"h3", "h2"}},
+		{"clear", []string{"clear"}, nil},
+		{"clear after entries", []string{`h3=":443"`, "clear"}, nil},
+		{"invalid entries", []string{`h3, =":443", ; ma=1`}, nil},
+	}
+	for _, tt := range tests {
+		if got := ParseAltSvc(tt.values); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", `h3=":443"; ma=86400`)
+	}))
+	srv.EnableHTTP2 = true
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		http2 bool
+		want  string
+	}{
+		{false, "http/1.1"},
+		{true, "h2"},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, HTTP2: tt.http2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := c.SendRequest(context.Background(), "GET", srv.URL, nil, "")
+		if err != nil || r.StatusCode != 200 {
+			t.Fatalf("HTTP2 %v: %v %d %s", tt.http2, err, r.StatusCode, r.Error)
+		}
+		if r.Protocol != tt.want || r.TLS == nil || r.TLS.ALPN != map[bool]string{true: "h2"}[tt.http2] {
+			t.Errorf("HTTP2 %v: protocol %q, want %q", tt.http2, r.Protocol, tt.want)
+		}
+		if !reflect.DeepEqual(r.AltSvc, []string{"h3"}) {
+			t.Errorf("HTTP2 %v: AltSvc %q", tt.http2, r.AltSvc)
+		}
+	}
+}
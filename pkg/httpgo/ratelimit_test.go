@@ -0,0 +1,59 @@
+package httpgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if NewRateLimiter(0, 1) != nil {
+		t.Error("rate 0 should not limit")
+	}
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Wait(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	// 每秒20个，桶容量1：第一个立即通过，之后每个间隔约50ms
+	l := NewRateLimiter(20, 1)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 200*time.Millisecond || d > 2*time.Second {
+		t.Errorf("6 tokens at 20/s took %v, want about 250ms", d)
+	}
+
+	// 桶容量允许的突发请求不等待
+	b := NewRateLimiter(1, 5)
+	start = time.Now()
+	for i := 0; i < 5; i++ {
+		b.Wait(context.Background())
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("burst of 5 took %v", d)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := NewRateLimiter(2, 1)
+	l.Wait(context.Background())
+
+	// 取消的等待归还预留的令牌，不会让后面的请求多等
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want deadline exceeded", err)
+		}
+		cancel()
+	}
+	start := time.Now()
+	l.Wait(context.Background())
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("wait after cancellations took %v, want at most 500ms", d)
+	}
+}
@@ -0,0 +1,147 @@
+package httpgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RedirectPolicy 跳转跟随策略
+type RedirectPolicy string
+
+const (
+	RedirectAll      RedirectPolicy = "all"       // 跟随全部跳转（默认）
+	RedirectSameHost RedirectPolicy = "same-host" // 只跟随同一主机内的跳转
+	RedirectNone     RedirectPolicy = "none"      // 不跟随跳转
+)
+
+// DefaultMaxRedirects 未设置最大跳转次数时使用的默认值，与 net/http 一致
+const DefaultMaxRedirects = 10
+
+// ParseRedirectPolicy 解析命令行中的跳转策略
+func ParseRedirectPolicy(s string) (RedirectPolicy, error) {
+	switch p := RedirectPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return RedirectAll, nil
+	case RedirectAll, RedirectSameHost, RedirectNone:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid redirect policy '%s', expected none, same-host or all", s)
+}
+
+// 跳转方式
+const (
+	RedirectHTTP = "http" // 3xx 状态码 + Location
+	RedirectMeta = "meta" // <meta http-equiv="refresh">
+	RedirectJS   = "js"   // JavaScript 修改 location
+)
+
+// RedirectHop 跳转链中的一跳
+type RedirectHop struct {
+	Url         string // 本次请求的地址
+	StatusCode  int    // 本次请求的状态码
+	Location    string // 跳转目标（原始值，可能为相对地址）
+	Kind        string // 跳转方式：http、meta、js
+	NotFollowed bool   // 检测到但未跟随的 HTML 跳转，只会是跳转链的最后一跳
+}
+
+// FormatRedirects 将跳转链格式化为单行文本，如 http://a/ -[302]-> http://a/login -[meta]-> http://b/，
+// 未跟随的跳转显示为 -[meta, not followed]-> 加跳转目标
+func FormatRedirects(hops []RedirectHop, finalUrl string) string {
+	if len(hops) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, hop := range hops {
+		sb.WriteString(hop.Url)
+		if hop.NotFollowed {
+			target := hop.Location
+			if next, ok := resolveLocation(hop.Url, hop.Location); ok {
+				target = next.String()
+			}
+			sb.WriteString(" -[" + hop.Kind + ", not followed]-> " + target)
+			return sb.String()
+		}
+		if hop.Kind == RedirectHTTP {
+			sb.WriteString(" -[" + strconv.Itoa(hop.StatusCode) + "]-> ")
+		} else {
+			sb.WriteString(" -[" + hop.Kind + "]-> ")
+		}
+	}
+	sb.WriteString(finalUrl)
+	return sb.String()
+}
+
+// isRedirectStatus 需要跟随 Location 的状态码
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// redirectMethod 跳转后使用的请求方法和请求体，规则与 net/http 一致
+func redirectMethod(method string, body string, code int) (string, string) {
+	switch code {
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return method, body
+	case http.StatusSeeOther:
+		if method != http.MethodHead {
+			return http.MethodGet, ""
+		}
+	case http.StatusMovedPermanently, http.StatusFound:
+		if method != http.MethodGet && method != http.MethodHead {
+			return http.MethodGet, ""
+		}
+	}
+	return method, ""
+}
+
+// nextURL 解析跳转目标，并按策略和最大跳转次数判断是否跟随
+func (c *Client) nextURL(origin string, current string, location string, hops int) (string, bool) {
+	if c.opts.Redirect == RedirectNone {
+		return "", false
+	}
+	max := c.opts.MaxRedirects
+	if max <= 0 {
+		max = DefaultMaxRedirects
+	}
+	if hops >= max {
+		return "", false
+	}
+
+	next, ok := resolveLocation(current, location)
+	if !ok {
+		return "", false
+	}
+
+	if c.opts.Redirect == RedirectSameHost {
+		o, err := url.Parse(origin)
+		if err != nil || !strings.EqualFold(o.Hostname(), next.Hostname()) {
+			return "", false
+		}
+	}
+	return next.String(), true
+}
+
+// resolveLocation 以 current 为基准解析跳转目标，只接受 http 和 https 地址
+func resolveLocation(current string, location string) (*url.URL, bool) {
+	base, err := url.Parse(current)
+	if err != nil {
+		return nil, false
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, false
+	}
+	next := base.ResolveReference(ref)
+	if next.Scheme != "http" && next.Scheme != "https" {
+		return nil, false
+	}
+	next.Fragment = ""
+	return next, true
+}
@@ -0,0 +1,112 @@
+package httpgo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedirects(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<title>other</title>")
+	}))
+	defer other.Close()
+	// 主机名与测试服务器不同
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/b", http.StatusFound) })
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "c", http.StatusMovedPermanently) })
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "<title>"+r.Method+" c</title>") })
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/loop", http.StatusFound) })
+	mux.HandleFunc("/post", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/c", http.StatusSeeOther) })
+	mux.HandleFunc("/external", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, otherURL+"/", http.StatusFound) })
+	mux.HandleFunc("/meta", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<html><head><meta http-equiv="refresh" content="0; url=/a"></head></html>`)
+	})
+	mux.HandleFunc("/js", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<script>window.location.href = "/c";</script>`)
+	})
+	mux.HandleFunc("/js-replace", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<script>location.replace('/c')</script>`)
+	})
+	mux.HandleFunc("/not-js", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<script>xlocation = "/a"; mylocation.href="/b";</script><title>not js</title>`)
+	})
+	mux.HandleFunc("/meta-loop", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<meta http-equiv="refresh" content="0;url=/meta-loop2">`)
+	})
+	mux.HandleFunc("/meta-loop2", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<meta http-equiv="refresh" content="0;url=/meta-loop">`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	follow := ClientOptions{Timeout: 5 * time.Second, FollowHTMLRedirects: true, MaxRedirects: 4}
+	tests := []struct {
+		name   string
+		opts   ClientOptions
+		method string
+		path   string
+		page   bool // 使用 GetPage 请求
+		status int
+		title  string
+		final  string
+		chain  string // 跳转链，S 表示测试服务器的地址
+	}{
+		{"3xx chain", ClientOptions{}, "GET", "/a", false, 200, "GET c", "S/c", "S/a -[302]-> S/b -[301]-> S/c"},
+		{"303 changes method", ClientOptions{}, "POST", "/post", false, 200, "GET c", "S/c", "S/post -[303]-> S/c"},
+		{"no redirect", ClientOptions{Redirect: RedirectNone}, "GET", "/a", false, 302, "", "S/a", ""},
+		{"same host", ClientOptions{Redirect: RedirectSameHost}, "GET", "/external", false, 302, "", "S/external", ""},
+		{"other host", ClientOptions{}, "GET", "/external", false, 200, "other", "O/", "S/external -[302]-> O/"},
+		{"loop limit", ClientOptions{MaxRedirects: 3}, "GET", "/loop", false, 302, "", "S/loop", "S/loop -[302]-> S/loop -[302]-> S/loop -[302]-> S/loop"},
+
+		// 未开启 FollowHTMLRedirects 时只记录跳转，不请求
+		{"meta not followed", ClientOptions{}, "GET", "/meta", true, 200, "", "S/meta", "S/meta -[meta, not followed]-> S/a"},
+		{"js not followed", ClientOptions{}, "GET", "/js", true, 200, "", "S/js", "S/js -[js, not followed]-> S/c"},
+		{"not a js redirect", ClientOptions{}, "GET", "/not-js", true, 200, "not js", "S/not-js", ""},
+
+		{"meta followed", follow, "GET", "/meta", true, 200, "GET c", "S/c", "S/meta -[meta]-> S/a -[302]-> S/b -[301]-> S/c"},
+		{"js followed", follow, "GET", "/js", true, 200, "GET c", "S/c", "S/js -[js]-> S/c"},
+		{"js replace followed", follow, "GET", "/js-replace", true, 200, "GET c", "S/c", "S/js-replace -[js]-> S/c"},
+		{"meta loop limit", follow, "GET", "/meta-loop", true, 200, "", "S/meta-loop",
+			"S/meta-loop -[meta]-> S/meta-loop2 -[meta]-> S/meta-loop -[meta]-> S/meta-loop2 -[meta]-> S/meta-loop"},
+
+		// favicon、路径探测等请求不处理 HTML 跳转
+		{"meta ignored", follow, "GET", "/meta", false, 200, "", "S/meta", ""},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		if opts.Timeout == 0 {
+			opts.Timeout = 5 * time.Second
+		}
+		c, err := NewClient(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r *Response
+		if tt.page {
+			r, err = c.GetPage(context.Background(), srv.URL+tt.path)
+		} else {
+			r, err = c.SendRequest(context.Background(), tt.method, srv.URL+tt.path, nil, "")
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		short := strings.NewReplacer(srv.URL, "S", otherURL, "O")
+		if r.StatusCode != tt.status || r.Title != tt.title {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, r.StatusCode, r.Title, tt.status, tt.title)
+		}
+		if got := short.Replace(r.FinalUrl); got != tt.final {
+			t.Errorf("%s: FinalUrl = %s, want %s", tt.name, got, tt.final)
+		}
+		if got := short.Replace(FormatRedirects(r.Redirects, r.FinalUrl)); got != tt.chain {
+			t.Errorf("%s: chain = %s, want %s", tt.name, got, tt.chain)
+		}
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,129 @@
+package httpgo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 请求失败的错误类型
+const (
+	ErrorDNS      = "dns"      // 域名解析失败
+	ErrorRefused  = "refused"  // 连接被拒绝
+	ErrorTimeout  = "timeout"  // 连接或读取超时
+	ErrorTLS      = "tls"      // TLS 握手失败
+	ErrorReset    = "reset"    // 连接被重置或提前关闭
+	ErrorCanceled = "canceled" // 扫描被取消
+	ErrorOther    = "other"
+)
+
+// ClassifyError 判断请求错误的类型，err 为 nil 时返回空字符串
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorDNS
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorCanceled
+	}
+	if isAny(err, refusedErrnos) {
+		return ErrorRefused
+	}
+	if isAny(err, resetErrnos) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorReset
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) || strings.Contains(err.Error(), "tls: ") {
+		return ErrorTLS
+	}
+	if strings.Contains(err.Error(), "connection reset") {
+		return ErrorReset
+	}
+	return ErrorOther
+}
+
+// isAny 判断 err 是否为 targets 中的任意一个错误
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy 请求失败时的重试策略，只重试 GET/HEAD 请求的超时、连接重置和 5xx 响应
+type RetryPolicy struct {
+	Retries    int           // 失败后的重试次数，0 表示不重试
+	Backoff    time.Duration // 第一次重试前的等待时间，之后每次翻倍，0 使用默认值 500ms
+	MaxBackoff time.Duration // 单次等待的上限，0 使用默认值 10s
+}
+
+// retryable 判断本次结果是否需要重试，POST 等非幂等的探测请求不重试，以免重复提交
+func retryable(method string, statusCode int, err error) bool {
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	if err != nil {
+		switch ClassifyError(err) {
+		case ErrorTimeout, ErrorReset:
+			return true
+		}
+		return false
+	}
+	return statusCode >= 500
+}
+
+// wait 第 attempt 次重试前按指数退避等待，等待时间在 [d/2, d) 之间随机以分散重试
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+
+	d := backoff
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
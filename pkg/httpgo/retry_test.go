@@ -0,0 +1,178 @@
+package httpgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closedAddr 返回一个已关闭的本地端口，连接时被拒绝
+func closedAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+// serveTCP 启动本地TCP服务，每个连接交给 handle 处理
+func serveTCP(t *testing.T, handle func(net.Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClassifyError(t *testing.T) {
+	// 自签名证书的服务器，校验证书时握手失败
+	tlsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	// 接受连接后不响应
+	silent := serveTCP(t, func(conn net.Conn) {
+		time.Sleep(2 * time.Second)
+		conn.Close()
+	})
+	// 读取请求后以 RST 关闭连接
+	reset := serveTCP(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 1024))
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+	})
+	// 读取请求后直接关闭连接
+	eof := serveTCP(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 1024))
+		conn.Close()
+	})
+
+	// get 使用校验证书的客户端请求，返回实际的请求错误
+	get := func(urlStr string) error {
+		client := &http.Client{Timeout: 300 * time.Millisecond}
+		resp, err := client.Get(urlStr)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"dns", &url.Error{Op: "Get", URL: "http://nx.invalid/", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "nx.invalid", IsNotFound: true}}}, ErrorDNS},
+		{"refused", get("http://" + closedAddr(t) + "/"), ErrorRefused},
+		{"timeout", get("http://" + silent + "/"), ErrorTimeout},
+		{"deadline", context.DeadlineExceeded, ErrorTimeout},
+		{"tls handshake", get(tlsServer.URL), ErrorTLS},
+		{"tls to plain server", get("https://" + eof + "/"), ErrorReset},
+		{"reset", get("http://" + reset + "/"), ErrorReset},
+		{"eof", get("http://" + eof + "/"), ErrorReset},
+		{"canceled", context.Canceled, ErrorCanceled},
+		{"other", errors.New("unexpected"), ErrorOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryMethods(t *testing.T) {
+	var gets, posts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			atomic.AddInt32(&posts, 1)
+		} else {
+			atomic.AddInt32(&gets, 1)
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{Timeout: 2 * time.Second, Retry: RetryPolicy{Retries: 2, Backoff: time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := c.SendRequest(ctx, "GET", srv.URL, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	// POST 探测请求可能有副作用，不重试
+	if _, err := c.SendRequest(ctx, "POST", srv.URL, nil, "a=1"); err != nil {
+		t.Fatal(err)
+	}
+	if g, p := atomic.LoadInt32(&gets), atomic.LoadInt32(&posts); g != 3 || p != 1 {
+		t.Errorf("got %d GET and %d POST requests, want 3 and 1", g, p)
+	}
+}
+
+func TestFallbackOnlyOnTLSError(t *testing.T) {
+	var conns int32
+	// 读取请求后直接关闭连接，不是握手错误，不改用备用密码套件
+	eof := serveTCP(t, func(conn net.Conn) {
+		atomic.AddInt32(&conns, 1)
+		conn.Read(make([]byte, 1024))
+		conn.Close()
+	})
+	// 收到 ClientHello 后返回 handshake_failure 告警（如没有共同的密码套件），改用备用密码套件再试一次
+	alert := serveTCP(t, func(conn net.Conn) {
+		atomic.AddInt32(&conns, 1)
+		conn.Read(make([]byte, 1024))
+		conn.Write([]byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28})
+		conn.Close()
+	})
+
+	c, err := NewClient(ClientOptions{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url   string
+		class string
+		conns int32
+	}{
+		{"http://" + eof + "/", ErrorReset, 1},
+		{"https://" + closedAddr(t) + "/", ErrorRefused, 0},
+		{"https://" + alert + "/", ErrorTLS, 2},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&conns, 0)
+		r, err := c.SendRequest(context.Background(), "GET", tt.url, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.ErrorClass != tt.class {
+			t.Errorf("%s: got %q (%s), want %q", tt.url, r.ErrorClass, r.Error, tt.class)
+		}
+		if n := atomic.LoadInt32(&conns); n != tt.conns {
+			t.Errorf("%s: got %d connections, want %d", tt.url, n, tt.conns)
+		}
+	}
+}
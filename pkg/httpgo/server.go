@@ -1,7 +1,6 @@
 package httpgo
 
 import (
-	"fmt"
 	"log"
 	"net"
 	"net/http"
@@ -77,7 +76,7 @@ func GetLocalIP() string {
 	// 获取本地机器的所有网络接口
 	addrs, err := net.Interfaces()
 	if err != nil {
-		fmt.Println("获取网卡信息失败:", err)
+		log.Println("获取网卡信息失败:", err)
 		return "0.0.0.0"
 	}
 
@@ -0,0 +1,88 @@
+package httpgo
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+)
+
+// HasScheme 判断目标是否已带有协议，如 http://、https://
+func HasScheme(target string) bool {
+	return strings.Contains(target, "://")
+}
+
+// WithSchemes 为不带协议的目标（host、host:port）生成 https 和 http 两个url，已带协议的目标原样返回
+func WithSchemes(target string) []string {
+	target = strings.TrimSpace(target)
+	if HasScheme(target) {
+		return []string{target}
+	}
+	return []string{"https://" + target, "http://" + target}
+}
+
+// httpsPortMessages 服务器收到发往TLS端口的明文HTTP请求时返回的400页面特征
+var httpsPortMessages = [][]byte{
+	[]byte("client sent an http request to an https server"), // Go net/http
+	[]byte("plain http request was sent to https port"),      // nginx
+	[]byte("speaking plain http to an ssl-enabled server"),   // Apache
+	[]byte("this combination of host and port requires tls"), // Tomcat
+}
+
+// IsHTTPOnTLSPort 判断响应是否为向TLS端口发送明文HTTP请求得到的400错误
+func IsHTTPOnTLSPort(r *Response) bool {
+	if r == nil || r.StatusCode != 400 {
+		return false
+	}
+	body := bytes.ToLower(r.Body)
+	for _, msg := range httpsPortMessages {
+		if bytes.Contains(body, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectScheme 为不带协议的目标选择可用的协议并返回其响应；已带协议的目标直接请求。
+// 默认先尝试 https，端口为 80 时先尝试 http；明文请求得到“发往HTTPS端口”的400错误时改用 https。
+func (c *Client) DetectScheme(ctx context.Context, target string) (string, *Response, error) {
+	target = strings.TrimSpace(target)
+	if HasScheme(target) {
+		r, err := c.GetPage(ctx, target)
+		return target, r, err
+	}
+
+	schemes := []string{"https", "http"}
+	host := target
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if _, port, err := net.SplitHostPort(host); err == nil && port == "80" {
+		schemes = []string{"http", "https"}
+	}
+
+	var first *Response
+	for _, scheme := range schemes {
+		urlStr := scheme + "://" + target
+		r, err := c.GetPage(ctx, urlStr)
+		if err != nil {
+			return urlStr, nil, err
+		}
+		if scheme == "http" && IsHTTPOnTLSPort(r) {
+			// TLS端口，明文响应没有意义
+			if first != nil {
+				return "https://" + target, first, nil
+			}
+			urlStr = "https://" + target
+			r, err = c.GetPage(ctx, urlStr)
+			return urlStr, r, err
+		}
+		if r.StatusCode != -1 || ctx.Err() != nil {
+			return urlStr, r, nil
+		}
+		if first == nil {
+			first = r
+		}
+	}
+	return schemes[0] + "://" + target, first, nil
+}
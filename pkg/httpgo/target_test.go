@@ -0,0 +1,128 @@
+package httpgo
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSchemes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"example.com", []string{"https://example.com", "http://example.com"}},
+		{" example.com:8080/path ", []string{"https://example.com:8080/path", "http://example.com:8080/path"}},
+		{"http://example.com", []string{"http://example.com"}},
+		{"https://example.com:8443", []string{"https://example.com:8443"}},
+	}
+	for _, tt := range tests {
+		if got := WithSchemes(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WithSchemes(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsHTTPOnTLSPort(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{400, "Client sent an HTTP request to an HTTPS server.\n", true},
+		{400, "<center>The plain HTTP request was sent to HTTPS port</center>", true},
+		{400, "You're speaking plain HTTP to an SSL-enabled server port.", true},
+		{400, "Bad Request", false},
+		{200, "client sent an http request to an https server", false},
+	}
+	for _, tt := range tests {
+		if got := IsHTTPOnTLSPort(&Response{StatusCode: tt.status, Body: []byte(tt.body)}); got != tt.want {
+			t.Errorf("IsHTTPOnTLSPort(%d %q) = %v, want %v", tt.status, tt.body, got, tt.want)
+		}
+	}
+	if IsHTTPOnTLSPort(nil) {
+		t.Error("nil response")
+	}
+}
+
+func TestDetectScheme(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<title>ok</title>")
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	// TLS 握手失败，明文请求返回 nginx 的“发往HTTPS端口”400页面，如只接受特定客户端证书的 HTTPS 服务
+	tlsOnly := serveTCP(t, func(conn net.Conn) {
+		defer conn.Close()
+		buf := make([]byte, 1024)
+		n, _ := conn.Read(buf)
+		if n > 0 && buf[0] == 0x16 {
+			return
+		}
+		io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\nContent-Length: 48\r\nConnection: close\r\n\r\nThe plain HTTP request was sent to HTTPS port\r\n\r\n")
+	})
+
+	c, err := NewClient(ClientOptions{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := func(srv *httptest.Server) string {
+		return srv.Listener.Addr().String()
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		wantURL string
+		status  int
+		class   string
+	}{
+		{"plain server", host(plain), "http://" + host(plain), 200, ""},
+		{"tls server", host(tlsServer), "https://" + host(tlsServer), 200, ""},
+		{"path kept", host(plain) + "/index", "http://" + host(plain) + "/index", 200, ""},
+		{"scheme given", "http://" + host(tlsServer), "http://" + host(tlsServer), 400, ""},
+		// 明文请求得到“发往HTTPS端口”的400错误时仍使用 https 的结果
+		{"http on tls port", tlsOnly, "https://" + tlsOnly, -1, ErrorReset},
+		{"both closed", closedAddr(t), "", -1, ErrorRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urlStr, r, err := c.DetectScheme(context.Background(), tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantURL == "" {
+				tt.wantURL = "https://" + tt.target
+			}
+			if urlStr != tt.wantURL || r.StatusCode != tt.status || r.ErrorClass != tt.class {
+				t.Errorf("got %s %d %q (%s), want %s %d %q", urlStr, r.StatusCode, r.ErrorClass, r.Error, tt.wantURL, tt.status, tt.class)
+			}
+		})
+	}
+
+	// 直接请求 TLS 端口的明文响应
+	r, err := c.GetPage(context.Background(), "http://"+host(tlsServer))
+	if err != nil || !IsHTTPOnTLSPort(r) {
+		t.Errorf("plain request to a TLS port: %v %d %q", err, r.StatusCode, r.Body)
+	}
+
+	// 扫描取消时不再尝试另一个协议
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	urlStr, r, _ := c.DetectScheme(ctx, host(plain))
+	if !strings.HasPrefix(urlStr, "https://") || r.ErrorClass != ErrorCanceled {
+		t.Errorf("cancelled: got %s %q", urlStr, r.ErrorClass)
+	}
+}
@@ -0,0 +1,85 @@
+package httpgo
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing 请求各阶段耗时。复用连接时 DNS、Connect、TLS 为0
+type Timing struct {
+	DNS     time.Duration // 域名解析
+	Connect time.Duration // TCP 连接
+	TLS     time.Duration // TLS 握手
+	TTFB    time.Duration // 从开始请求到收到响应首字节
+	Total   time.Duration // 整个请求（含跳转与读取响应内容）
+}
+
+// tracer 通过 httptrace 记录最后一次发送的请求的各阶段耗时，回调可能在不同goroutine中执行
+type tracer struct {
+	mu                                   sync.Mutex
+	start, dnsStart, connStart, tlsStart time.Time
+	t                                    Timing
+}
+
+// withTrace 返回带有 httptrace 回调的 ctx
+func (tr *tracer) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// 重试和改用备用客户端时重新计时
+			tr.mu.Lock()
+			tr.start = time.Now()
+			tr.t = Timing{}
+			tr.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tr.mu.Lock()
+			tr.dnsStart = time.Now()
+			tr.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tr.mu.Lock()
+			tr.t.DNS = time.Since(tr.dnsStart)
+			tr.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			tr.mu.Lock()
+			tr.connStart = time.Now()
+			tr.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err != nil {
+				return
+			}
+			tr.mu.Lock()
+			tr.t.Connect = time.Since(tr.connStart)
+			tr.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			tr.mu.Lock()
+			tr.tlsStart = time.Now()
+			tr.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tr.mu.Lock()
+			tr.t.TLS = time.Since(tr.tlsStart)
+			tr.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			tr.mu.Lock()
+			tr.t.TTFB = time.Since(tr.start)
+			tr.mu.Unlock()
+		},
+	})
+}
+
+// timing 返回记录的耗时，total 为整个请求的耗时
+func (tr *tracer) timing(total time.Duration) Timing {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	t := tr.t
+	t.Total = total
+	return t
+}
@@ -0,0 +1,57 @@
+package httpgo
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTiming(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	// 使用域名访问才会经过域名解析
+	target := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.SendRequest(context.Background(), "GET", target, nil, "")
+	if err != nil || r.StatusCode != 200 {
+		t.Fatalf("request: %v %d %s", err, r.StatusCode, r.Error)
+	}
+	tm := r.Timing
+	if tm.DNS <= 0 || tm.Connect <= 0 || tm.TLS <= 0 || tm.TTFB <= 0 || tm.Total <= 0 {
+		t.Fatalf("missing phase: %+v", tm)
+	}
+	// 各阶段依次发生，之和不超过首字节时间，首字节时间不超过总耗时
+	if tm.DNS+tm.Connect+tm.TLS > tm.TTFB || tm.TTFB > tm.Total {
+		t.Errorf("phases not monotonic: %+v", tm)
+	}
+	if tm.TTFB < 20*time.Millisecond {
+		t.Errorf("TTFB %v shorter than the server delay", tm.TTFB)
+	}
+
+	// 复用连接时没有解析、连接和握手耗时
+	r, err = c.SendRequest(context.Background(), "GET", target, nil, "")
+	if err != nil || r.StatusCode != 200 {
+		t.Fatalf("second request: %v %d %s", err, r.StatusCode, r.Error)
+	}
+	tm = r.Timing
+	if tm.DNS != 0 || tm.Connect != 0 || tm.TLS != 0 {
+		t.Errorf("reused connection: %+v", tm)
+	}
+	if tm.TTFB <= 0 || tm.TTFB > tm.Total {
+		t.Errorf("reused connection TTFB: %+v", tm)
+	}
+}
@@ -0,0 +1,173 @@
+package httpgo
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCertExpiryDays 证书在多少天内到期时记录为问题
+const DefaultCertExpiryDays = 30
+
+// TLS 问题类型
+const (
+	IssueCertExpired      = "cert-expired"       // 证书已过期
+	IssueCertExpiring     = "cert-expiring"      // 证书即将到期
+	IssueCertNotYetValid  = "cert-not-yet-valid" // 证书尚未生效
+	IssueSelfSigned       = "self-signed"        // 自签名证书
+	IssueHostnameMismatch = "hostname-mismatch"  // 证书与访问的主机名不匹配
+	IssueSHA1Signature    = "sha1-signature"     // 证书使用 SHA-1 签名
+	IssueWeakRSAKey       = "weak-rsa-key"       // RSA 密钥小于2048位
+	IssueTLS10            = "tls1.0"             // 接受 TLS 1.0
+	IssueTLS11            = "tls1.1"             // 接受 TLS 1.1
+	IssueRC4              = "rc4"                // 接受 RC4 密码套件
+	Issue3DES             = "3des"               // 接受 3DES 密码套件
+)
+
+// TLSIssue 一项证书或TLS配置问题
+type TLSIssue struct {
+	Type   string // 问题类型，如 cert-expired、tls1.0
+	Detail string // 补充信息，如到期时间、协商的密码套件
+}
+
+func (i TLSIssue) String() string {
+	if i.Detail == "" {
+		return i.Type
+	}
+	return i.Type + "(" + i.Detail + ")"
+}
+
+// FormatTLSIssues 以 ; 连接问题列表，用于报告
+func FormatTLSIssues(issues []TLSIssue) string {
+	s := make([]string, len(issues))
+	for i, issue := range issues {
+		s[i] = issue.String()
+	}
+	return strings.Join(s, ";")
+}
+
+// CheckCert 检查站点证书：过期或 expiryDays 天内到期（expiryDays <= 0 时只检查是否已过期）、尚未生效、
+// 自签名、与 host 不匹配、SHA-1 签名、RSA 密钥过短
+func CheckCert(info *TLSInfo, host string, expiryDays int, now time.Time) []TLSIssue {
+	leaf := info.Leaf()
+	if leaf == nil || leaf.Raw == nil {
+		return nil
+	}
+	cert := leaf.Raw
+
+	var issues []TLSIssue
+	notAfter := cert.NotAfter.Format("2006-01-02")
+	if now.After(cert.NotAfter) {
+		issues = append(issues, TLSIssue{Type: IssueCertExpired, Detail: notAfter})
+	} else if expiryDays > 0 && now.AddDate(0, 0, expiryDays).After(cert.NotAfter) {
+		issues = append(issues, TLSIssue{Type: IssueCertExpiring, Detail: notAfter})
+	}
+	if now.Before(cert.NotBefore) {
+		issues = append(issues, TLSIssue{Type: IssueCertNotYetValid, Detail: cert.NotBefore.Format("2006-01-02")})
+	}
+	if leaf.SelfSigned {
+		issues = append(issues, TLSIssue{Type: IssueSelfSigned})
+	}
+	if host != "" && cert.VerifyHostname(host) != nil {
+		issues = append(issues, TLSIssue{Type: IssueHostnameMismatch, Detail: host})
+	}
+	switch cert.SignatureAlgorithm {
+	case x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		issues = append(issues, TLSIssue{Type: IssueSHA1Signature, Detail: cert.SignatureAlgorithm.String()})
+	}
+	if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < 2048 {
+		issues = append(issues, TLSIssue{Type: IssueWeakRSAKey, Detail: strconv.Itoa(key.N.BitLen())})
+	}
+	return issues
+}
+
+// weakProbe 一次受限的TLS握手，握手成功说明服务器接受对应的版本或密码套件
+type weakProbe struct {
+	issue   string
+	version uint16
+	suites  []uint16
+}
+
+// weakProbes 旧版本协议与弱密码套件的探测
+var weakProbes = []weakProbe{
+	{IssueTLS10, tls.VersionTLS10, nil},
+	{IssueTLS11, tls.VersionTLS11, nil},
+	{IssueRC4, tls.VersionTLS12, []uint16{
+		tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+		tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+		tls.TLS_RSA_WITH_RC4_128_SHA,
+	}},
+	{Issue3DES, tls.VersionTLS12, []uint16{
+		tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+		tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	}},
+}
+
+// ProbeWeakTLS 探测服务器是否接受 TLS 1.0/1.1 以及 RC4、3DES 密码套件，
+// addr 为 host:port，serverName 为发送的 SNI。每项探测单独建立一个TLS连接
+func (c *Client) ProbeWeakTLS(ctx context.Context, addr, serverName string) []TLSIssue {
+	var issues []TLSIssue
+	for _, p := range weakProbes {
+		if ctx.Err() != nil {
+			break
+		}
+		state, err := c.handshake(ctx, addr, &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+			MinVersion:         p.version,
+			MaxVersion:         p.version,
+			CipherSuites:       p.suites,
+		})
+		if err != nil {
+			continue
+		}
+		detail := ""
+		if p.suites != nil {
+			detail = tls.CipherSuiteName(state.CipherSuite)
+		}
+		issues = append(issues, TLSIssue{Type: p.issue, Detail: detail})
+	}
+	return issues
+}
+
+// handshake 建立连接并完成TLS握手后关闭连接
+func (c *Client) handshake(ctx context.Context, addr string, config *tls.Config) (tls.ConnectionState, error) {
+	conn, err := c.dialTCP(ctx, addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	if c.opts.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.opts.Timeout))
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return tlsConn.ConnectionState(), nil
+}
+
+// TLSIssues 检查 urlStr 的证书问题，开启 TLSCheck 时同时探测旧版本协议和弱密码套件
+func (c *Client) TLSIssues(ctx context.Context, urlStr string, info *TLSInfo) []TLSIssue {
+	u, err := url.Parse(urlStr)
+	if err != nil || info == nil {
+		return nil
+	}
+	issues := CheckCert(info, u.Hostname(), c.opts.CertExpiryDays, time.Now())
+
+	if c.opts.TLSCheck && u.Scheme == "https" {
+		port := u.Port()
+		if port == "" {
+			port = "443"
+		}
+		issues = append(issues, c.ProbeWeakTLS(ctx, net.JoinHostPort(u.Hostname(), port), info.ServerName)...)
+	}
+	return issues
+}
@@ -0,0 +1,233 @@
+package httpgo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// rsaKey 测试共用的 2048 位密钥，生成较慢只生成一次
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	testKeyOnce.Do(func() {
+		var err error
+		if testKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return testKey
+}
+
+// certOptions 生成测试证书的参数
+type certOptions struct {
+	cn        string
+	dnsNames  []string
+	ips       []net.IP
+	notBefore time.Time
+	notAfter  time.Time
+	key       *rsa.PrivateKey // 为 nil 时使用 rsaKey
+	isCA      bool
+}
+
+// newCert 生成证书，parent 为 nil 时为自签名证书
+func newCert(t *testing.T, o certOptions, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, tls.Certificate) {
+	t.Helper()
+	key := o.key
+	if key == nil {
+		key = rsaKey(t)
+	}
+	if o.notBefore.IsZero() {
+		o.notBefore = time.Now().Add(-time.Hour)
+	}
+	if o.notAfter.IsZero() {
+		o.notAfter = time.Now().AddDate(1, 0, 0)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: o.cn},
+		DNSNames:              o.dnsNames,
+		IPAddresses:           o.ips,
+		NotBefore:             o.notBefore,
+		NotAfter:              o.notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  o.isCA,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}
+}
+
+func issueTypes(issues []TLSIssue) []string {
+	types := []string{}
+	for _, issue := range issues {
+		types = append(types, issue.Type)
+	}
+	return types
+}
+
+func TestCheckCert(t *testing.T) {
+	now := time.Now()
+	ca, _ := newCert(t, certOptions{cn: "Test CA", isCA: true}, nil, nil)
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       certOptions
+		selfSigned bool
+		host       string
+		expiryDays int
+		want       []string
+	}{
+		{name: "valid", opts: certOptions{cn: "example.com", dnsNames: []string{"example.com"}}, host: "example.com", expiryDays: 30, want: []string{}},
+		{name: "wildcard", opts: certOptions{dnsNames: []string{"*.example.com"}}, host: "a.example.com", expiryDays: 30, want: []string{}},
+		{name: "ip", opts: certOptions{ips: []net.IP{net.ParseIP("10.0.0.1")}}, host: "10.0.0.1", expiryDays: 30, want: []string{}},
+		{name: "expired", opts: certOptions{dnsNames: []string{"example.com"}, notBefore: now.AddDate(-1, 0, 0), notAfter: now.AddDate(0, 0, -1)}, host: "example.com", expiryDays: 30, want: []string{IssueCertExpired}},
+		{name: "expired with expiry check off", opts: certOptions{dnsNames: []string{"example.com"}, notBefore: now.AddDate(-1, 0, 0), notAfter: now.AddDate(0, 0, -1)}, host: "example.com", expiryDays: 0, want: []string{IssueCertExpired}},
+		{name: "expiring", opts: certOptions{dnsNames: []string{"example.com"}, notAfter: now.AddDate(0, 0, 10)}, host: "example.com", expiryDays: 30, want: []string{IssueCertExpiring}},
+		{name: "expiring outside window", opts: certOptions{dnsNames: []string{"example.com"}, notAfter: now.AddDate(0, 0, 10)}, host: "example.com", expiryDays: 7, want: []string{}},
+		// 0 与负数只检查是否已过期
+		{name: "expiring with expiry check off", opts: certOptions{dnsNames: []string{"example.com"}, notAfter: now.AddDate(0, 0, 10)}, host: "example.com", expiryDays: 0, want: []string{}},
+		{name: "expiring with negative days", opts: certOptions{dnsNames: []string{"example.com"}, notAfter: now.AddDate(0, 0, 10)}, host: "example.com", expiryDays: -1, want: []string{}},
+		{name: "not yet valid", opts: certOptions{dnsNames: []string{"example.com"}, notBefore: now.AddDate(0, 0, 1)}, host: "example.com", expiryDays: 30, want: []string{IssueCertNotYetValid}},
+		{name: "self-signed", opts: certOptions{cn: "localhost", dnsNames: []string{"example.com"}}, selfSigned: true, host: "example.com", expiryDays: 30, want: []string{IssueSelfSigned}},
+		{name: "hostname mismatch", opts: certOptions{cn: "example.com", dnsNames: []string{"example.com"}}, host: "other.com", expiryDays: 30, want: []string{IssueHostnameMismatch}},
+		{name: "no host", opts: certOptions{dnsNames: []string{"example.com"}}, expiryDays: 30, want: []string{}},
+		{name: "weak key", opts: certOptions{dnsNames: []string{"example.com"}, key: weakKey}, host: "example.com", expiryDays: 30, want: []string{IssueWeakRSAKey}},
+		{name: "all", opts: certOptions{cn: "x", notBefore: now.AddDate(-1, 0, 0), notAfter: now.AddDate(0, 0, -1), key: weakKey}, selfSigned: true, host: "example.com", expiryDays: 30,
+			want: []string{IssueCertExpired, IssueSelfSigned, IssueHostnameMismatch, IssueWeakRSAKey}},
+	}
+	for _, tt := range tests {
+		var cert *x509.Certificate
+		if tt.selfSigned {
+			cert, _ = newCert(t, tt.opts, nil, nil)
+		} else {
+			cert, _ = newCert(t, tt.opts, ca, rsaKey(t))
+		}
+		info := &TLSInfo{Certs: []CertInfo{newCertInfo(cert), newCertInfo(ca)}}
+		got := issueTypes(CheckCert(info, tt.host, tt.expiryDays, now))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if issues := CheckCert(nil, "example.com", 30, now); issues != nil {
+		t.Errorf("no TLS: got %v", issues)
+	}
+}
+
+// newTLSServer 使用指定证书和配置启动 HTTPS 服务
+func newTLSServer(t *testing.T, cert tls.Certificate, config *tls.Config) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config.Certificates = []tls.Certificate{cert}
+	srv.TLS = config
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTLSIssues(t *testing.T) {
+	now := time.Now()
+	// 过期、自签名且与访问地址 127.0.0.1 不匹配的证书
+	_, cert := newCert(t, certOptions{cn: "example.com", dnsNames: []string{"example.com"}, notBefore: now.AddDate(-1, 0, 0), notAfter: now.AddDate(0, 0, -1)}, nil, nil)
+	srv := newTLSServer(t, cert, nil)
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, CertExpiryDays: DefaultCertExpiryDays})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.SendRequest(context.Background(), "GET", srv.URL, nil, "")
+	if err != nil || r.TLS == nil {
+		t.Fatalf("request: %v %+v", err, r)
+	}
+	issues := c.TLSIssues(context.Background(), srv.URL, r.TLS)
+	if got, want := issueTypes(issues), []string{IssueCertExpired, IssueSelfSigned, IssueHostnameMismatch}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := FormatTLSIssues(issues); got != "cert-expired("+cert.Leaf.NotAfter.Format("2006-01-02")+");self-signed;hostname-mismatch(127.0.0.1)" {
+		t.Errorf("FormatTLSIssues = %s", got)
+	}
+	if c.TLSIssues(context.Background(), "http://127.0.0.1/", nil) != nil {
+		t.Error("plain HTTP should have no TLS issues")
+	}
+}
+
+func TestProbeWeakTLS(t *testing.T) {
+	_, cert := newCert(t, certOptions{cn: "example.com", ips: []net.IP{net.ParseIP("127.0.0.1")}}, nil, nil)
+	// 接受 TLS 1.0 和 3DES 的旧服务器
+	legacy := newTLSServer(t, cert, &tls.Config{
+		MinVersion: tls.VersionTLS10,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+		},
+	})
+	modern := newTLSServer(t, cert, &tls.Config{MinVersion: tls.VersionTLS12})
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, TLSCheck: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	addr := func(srv *httptest.Server) string {
+		u, _ := url.Parse(srv.URL)
+		return u.Host
+	}
+
+	issues := c.ProbeWeakTLS(ctx, addr(legacy), "example.com")
+	if got, want := FormatTLSIssues(issues), "tls1.0;tls1.1;3des(TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA)"; got != want {
+		t.Errorf("legacy server: got %s, want %s", got, want)
+	}
+	if issues := c.ProbeWeakTLS(ctx, addr(modern), "example.com"); len(issues) != 0 {
+		t.Errorf("modern server: got %v", issues)
+	}
+	// 连接失败时没有问题
+	if issues := c.ProbeWeakTLS(ctx, closedAddr(t), ""); len(issues) != 0 {
+		t.Errorf("closed port: got %v", issues)
+	}
+
+	// TLSIssues 在开启 TLSCheck 时同时探测
+	r, err := c.SendRequest(ctx, "GET", legacy.URL, nil, "")
+	if err != nil || r.TLS == nil {
+		t.Fatalf("request: %v", err)
+	}
+	got := issueTypes(c.TLSIssues(ctx, legacy.URL, r.TLS))
+	if want := []string{IssueSelfSigned, IssueTLS10, IssueTLS11, Issue3DES}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TLSIssues: got %v, want %v", got, want)
+	}
+}
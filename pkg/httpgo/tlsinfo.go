@@ -0,0 +1,90 @@
+package httpgo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"time"
+)
+
+// TLSInfo TLS 连接与证书信息，明文HTTP时为 nil
+type TLSInfo struct {
+	Version     string     // 协商的版本，如 TLS 1.3
+	CipherSuite string     // 协商的密码套件
+	ALPN        string     // ALPN 协商的协议，如 h2、http/1.1，未协商时为空
+	ServerName  string     // 发送的 SNI
+	Certs       []CertInfo // 服务器返回的证书链，第一个为站点证书
+}
+
+// CertInfo 单个证书的信息
+type CertInfo struct {
+	Subject    string
+	CommonName string
+	SANs       []string // 证书中的 DNS 名称和 IP 地址
+	Issuer     string
+	IssuerCN   string
+	NotBefore  time.Time
+	NotAfter   time.Time
+	Serial     string // 十六进制序列号
+	SHA256     string // 证书的 SHA-256 指纹（十六进制）
+	SelfSigned bool
+
+	Raw *x509.Certificate `json:"-"` // 原始证书，用于进一步检查
+}
+
+// Leaf 返回站点证书，没有证书时返回 nil
+func (t *TLSInfo) Leaf() *CertInfo {
+	if t == nil || len(t.Certs) == 0 {
+		return nil
+	}
+	return &t.Certs[0]
+}
+
+// newTLSInfo 从连接状态中提取 TLS 信息
+func newTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ALPN:        state.NegotiatedProtocol,
+		ServerName:  state.ServerName,
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certs = append(info.Certs, newCertInfo(cert))
+	}
+	return info
+}
+
+func newCertInfo(cert *x509.Certificate) CertInfo {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sum := sha256.Sum256(cert.Raw)
+	return CertInfo{
+		Subject:    cert.Subject.String(),
+		CommonName: cert.Subject.CommonName,
+		SANs:       sans,
+		Issuer:     cert.Issuer.String(),
+		IssuerCN:   cert.Issuer.CommonName,
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+		Serial:     cert.SerialNumber.Text(16),
+		SHA256:     hex.EncodeToString(sum[:]),
+		SelfSigned: isSelfSigned(cert),
+		Raw:        cert,
+	}
+}
+
+// isSelfSigned 证书的签发者与主体相同且能用自身公钥验证签名
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	// 不使用 CheckSignatureFrom，自签名的站点证书通常不是CA证书
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
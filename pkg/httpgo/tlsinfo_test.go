@@ -0,0 +1,46 @@
+package httpgo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestCertInfo(t *testing.T) {
+	ca, _ := newCert(t, certOptions{cn: "Test CA", isCA: true}, nil, nil)
+	leaf, _ := newCert(t, certOptions{cn: "example.com", dnsNames: []string{"example.com", "www.example.com"}, ips: []net.IP{net.ParseIP("10.0.0.1")}}, ca, rsaKey(t))
+
+	info := newCertInfo(leaf)
+	if info.CommonName != "example.com" || info.IssuerCN != "Test CA" || info.Issuer != "CN=Test CA" || info.Subject != "CN=example.com" {
+		t.Errorf("names: %+v", info)
+	}
+	if want := []string{"example.com", "www.example.com", "10.0.0.1"}; !reflect.DeepEqual(info.SANs, want) {
+		t.Errorf("SANs = %v, want %v", info.SANs, want)
+	}
+	if len(info.SHA256) != 64 || info.Serial != leaf.SerialNumber.Text(16) || !info.NotAfter.Equal(leaf.NotAfter) {
+		t.Errorf("fingerprint %s, serial %s, not after %v", info.SHA256, info.Serial, info.NotAfter)
+	}
+	if info.SelfSigned || !newCertInfo(ca).SelfSigned {
+		t.Error("only the CA certificate is self-signed")
+	}
+
+	if newTLSInfo(nil) != nil || (*TLSInfo)(nil).Leaf() != nil {
+		t.Error("plain HTTP should have no TLS info")
+	}
+	state := &tls.ConnectionState{
+		Version:            tls.VersionTLS12,
+		CipherSuite:        tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		NegotiatedProtocol: "h2",
+		ServerName:         "example.com",
+		PeerCertificates:   []*x509.Certificate{leaf, ca},
+	}
+	tlsInfo := newTLSInfo(state)
+	if tlsInfo.Version != "TLS 1.2" || tlsInfo.CipherSuite != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" || tlsInfo.ALPN != "h2" || tlsInfo.ServerName != "example.com" {
+		t.Errorf("connection: %+v", tlsInfo)
+	}
+	if len(tlsInfo.Certs) != 2 || tlsInfo.Leaf().CommonName != "example.com" || tlsInfo.Leaf().Raw != leaf {
+		t.Errorf("certificate chain: %+v", tlsInfo.Certs)
+	}
+}
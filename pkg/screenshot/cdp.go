@@ -0,0 +1,137 @@
+package screenshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/net/websocket"
+	"sync"
+	"sync/atomic"
+)
+
+// message DevTools 协议的消息，带 ID 的是命令的返回，带 Method 的是事件
+type message struct {
+	ID     int64           `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// errClosed 浏览器已退出或连接已断开
+var errClosed = errors.New("devtools connection closed")
+
+// conn 一个 DevTools WebSocket 连接，可被多个goroutine并发调用
+type conn struct {
+	ws     *websocket.Conn
+	nextID int64
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[int64]chan *message
+
+	events chan *message // 事件，读取不及时时丢弃
+	done   chan struct{}
+}
+
+// devtoolsOrigin 连接 DevTools 时使用的 Origin，浏览器启动时只允许该 Origin
+const devtoolsOrigin = "http://127.0.0.1"
+
+func dial(wsURL string) (*conn, error) {
+	ws, err := websocket.Dial(wsURL, "", devtoolsOrigin)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{
+		ws:      ws,
+		pending: make(map[int64]chan *message),
+		events:  make(chan *message, 64),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// read 分发命令的返回与事件，连接断开时结束所有等待中的命令
+func (c *conn) read() {
+	defer close(c.done)
+	for {
+		var msg message
+		if err := websocket.JSON.Receive(c.ws, &msg); err != nil {
+			return
+		}
+		if msg.ID == 0 {
+			select {
+			case c.events <- &msg:
+			default:
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- &msg
+		}
+	}
+}
+
+// call 发送命令并等待返回，result 为 nil 时忽略返回内容
+func (c *conn) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := atomic.AddInt64(&c.nextID, 1)
+	ch := make(chan *message, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	req := map[string]interface{}{"id": id, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	c.writeMu.Lock()
+	err := websocket.JSON.Send(c.ws, req)
+	c.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-c.done:
+		return errClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain 丢弃已收到的事件
+func (c *conn) drain() {
+	for {
+		select {
+		case <-c.events:
+		default:
+			return
+		}
+	}
+}
+
+func (c *conn) close() error {
+	return c.ws.Close()
+}
@@ -0,0 +1,403 @@
+package screenshot
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Dir 截图保存在输出目录下的子目录
+const Dir = "screenshots"
+
+// 默认配置
+const (
+	DefaultTabs    = 4
+	DefaultTimeout = 15 * time.Second
+)
+
+// Options 截图配置
+type Options struct {
+	Browser   string        // Chromium/Chrome 可执行文件路径，为空时自动查找
+	OutputDir string        // 输出目录，截图保存到其中的 screenshots 子目录
+	Tabs      int           // 同时打开的标签页数，0 使用默认值 4
+	Timeout   time.Duration // 等待页面加载的时间，超时后截取已加载的内容，0 使用默认值 15s
+	Proxy     string        // 浏览器使用的代理，如 http://127.0.0.1:8080、socks5://127.0.0.1:1080
+	Width     int           // 窗口大小，0 使用默认值 1280x800
+	Height    int
+}
+
+// ErrNoBrowser 未找到本地浏览器
+var ErrNoBrowser = errors.New("no Chromium or Chrome browser found")
+
+// Pool 通过 DevTools 协议驱动本地无头浏览器截图，标签页在多个goroutine间共享
+type Pool struct {
+	cmd     *exec.Cmd
+	dataDir string
+	browser *conn
+	host    string // DevTools 地址，用于重建断开的标签页
+	tabs    chan *tab
+	live    int32         // 可用的标签页数
+	noTabs  chan struct{} // 所有标签页都无法重建时关闭
+	timeout time.Duration
+	dir     string
+}
+
+// errNoTabs 所有标签页都已断开且无法重建
+var errNoTabs = errors.New("all browser tabs are closed")
+
+// tab 一个标签页的 DevTools 连接，同一时间只用于一个目标
+type tab struct {
+	c *conn
+}
+
+// browserNames PATH 中查找的浏览器名称
+var browserNames = []string{
+	"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome",
+	"microsoft-edge", "microsoft-edge-stable", "msedge",
+}
+
+// browserPaths 常见的浏览器安装路径
+var browserPaths = []string{
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+	"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+	`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+	`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+	`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`,
+	`C:\Program Files\Microsoft\Edge\Application\msedge.exe`,
+}
+
+// FindBrowser 查找本地安装的 Chromium/Chrome，未找到时返回空字符串
+func FindBrowser() string {
+	for _, name := range browserNames {
+		if p, err := exec.LookPath(name); err == nil {
+			return p
+		}
+	}
+	for _, p := range browserPaths {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// New 启动无头浏览器并打开 opts.Tabs 个标签页。未找到浏览器时返回 ErrNoBrowser
+func New(opts Options) (*Pool, error) {
+	browser := opts.Browser
+	if browser == "" {
+		browser = FindBrowser()
+	}
+	if browser == "" {
+		return nil, ErrNoBrowser
+	}
+	tabs := opts.Tabs
+	if tabs <= 0 {
+		tabs = DefaultTabs
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	width, height := opts.Width, opts.Height
+	if width <= 0 || height <= 0 {
+		width, height = 1280, 800
+	}
+
+	dataDir, err := os.MkdirTemp("", "httpgo-browser-")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"--headless=new",
+		"--disable-gpu",
+		"--hide-scrollbars",
+		"--mute-audio",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--ignore-certificate-errors",
+		"--remote-debugging-port=0",
+		// 只允许本程序连接 DevTools，浏览器打开的网页不能访问
+		"--remote-allow-origins=" + devtoolsOrigin,
+		"--user-data-dir=" + dataDir,
+		fmt.Sprintf("--window-size=%d,%d", width, height),
+	}
+	if os.Geteuid() == 0 {
+		// root 用户下浏览器无法启用沙箱
+		args = append(args, "--no-sandbox")
+	}
+	if opts.Proxy != "" {
+		args = append(args, "--proxy-server="+opts.Proxy)
+	}
+	args = append(args, "about:blank")
+
+	p := &Pool{
+		cmd:     exec.Command(browser, args...),
+		dataDir: dataDir,
+		tabs:    make(chan *tab, tabs),
+		noTabs:  make(chan struct{}),
+		timeout: timeout,
+		dir:     filepath.Join(opts.OutputDir, Dir),
+	}
+	wsURL, err := p.start()
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	if err := p.connect(wsURL, tabs); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// connect 连接浏览器的 DevTools 地址并打开 tabs 个标签页
+func (p *Pool) connect(wsURL string, tabs int) error {
+	var err error
+	if p.browser, err = dial(wsURL); err != nil {
+		return err
+	}
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return err
+	}
+	p.host = u.Host
+	for i := 0; i < tabs; i++ {
+		t, err := p.newTab()
+		if err != nil {
+			return err
+		}
+		p.tabs <- t
+		p.live++
+	}
+	return os.MkdirAll(p.dir, os.ModePerm)
+}
+
+// devtoolsRe 浏览器启动后输出的 DevTools 地址
+var devtoolsRe = regexp.MustCompile(`DevTools listening on (ws://\S+)`)
+
+// start 启动浏览器并从标准错误中读取 DevTools 地址
+func (p *Pool) start() (string, error) {
+	stderr, err := p.cmd.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := p.cmd.Start(); err != nil {
+		return "", err
+	}
+
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if m := devtoolsRe.FindStringSubmatch(scanner.Text()); m != nil {
+				found <- m[1]
+				break
+			}
+		}
+		close(found)
+		// 继续读取，避免管道写满阻塞浏览器
+		io.Copy(io.Discard, stderr)
+	}()
+
+	select {
+	case wsURL, ok := <-found:
+		if !ok {
+			return "", errors.New("browser exited before DevTools was ready")
+		}
+		return wsURL, nil
+	case <-time.After(20 * time.Second):
+		return "", errors.New("timed out waiting for browser DevTools")
+	}
+}
+
+// newTab 新建标签页并连接到它的 DevTools 地址
+func (p *Pool) newTab() (*tab, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := p.browser.call(ctx, "Target.createTarget", map[string]string{"url": "about:blank"}, &target); err != nil {
+		return nil, err
+	}
+	c, err := dial("ws://" + p.host + "/devtools/page/" + target.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	t := &tab{c: c}
+	if err := c.call(ctx, "Page.enable", nil, nil); err != nil {
+		c.close()
+		return nil, err
+	}
+	if err := c.call(ctx, "Page.setLifecycleEventsEnabled", map[string]bool{"enabled": true}, nil); err != nil {
+		c.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Capture 打开 urlStr 并截图，返回相对于输出目录的 PNG 文件路径
+func (p *Pool) Capture(ctx context.Context, urlStr string) (string, error) {
+	var t *tab
+	select {
+	case t = <-p.tabs:
+	case <-p.noTabs:
+		return "", errNoTabs
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer p.release(t)
+
+	// 页面加载超时后仍截取已加载的内容
+	loadCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	err := t.navigate(loadCtx, urlStr)
+	cancel()
+	if err != nil && !(errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil) {
+		return "", err
+	}
+
+	captureCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err != nil {
+		t.c.call(captureCtx, "Page.stopLoading", nil, nil)
+	}
+	data, err := t.capture(captureCtx)
+	if err != nil {
+		return "", err
+	}
+
+	name := fileName(urlStr)
+	if err := os.WriteFile(filepath.Join(p.dir, name), data, 0644); err != nil {
+		return "", err
+	}
+	return Dir + "/" + name, nil
+}
+
+// release 将标签页放回池中。连接已断开（如收到超过大小限制的消息）时换成新的标签页，
+// 无法新建时从池中移除
+func (p *Pool) release(t *tab) {
+	select {
+	case <-t.c.done:
+	default:
+		p.tabs <- t
+		return
+	}
+	t.c.close()
+	if nt, err := p.newTab(); err == nil {
+		p.tabs <- nt
+		return
+	}
+	if atomic.AddInt32(&p.live, -1) == 0 {
+		close(p.noTabs)
+	}
+}
+
+// navigate 打开页面并等待 load 事件
+func (t *tab) navigate(ctx context.Context, urlStr string) error {
+	t.c.drain()
+	var nav struct {
+		LoaderID  string `json:"loaderId"`
+		ErrorText string `json:"errorText"`
+	}
+	if err := t.c.call(ctx, "Page.navigate", map[string]string{"url": urlStr}, &nav); err != nil {
+		return err
+	}
+	if nav.ErrorText != "" {
+		return errors.New(nav.ErrorText)
+	}
+
+	for {
+		select {
+		case msg := <-t.c.events:
+			if msg.Method != "Page.lifecycleEvent" {
+				continue
+			}
+			var ev struct {
+				LoaderID string `json:"loaderId"`
+				Name     string `json:"name"`
+			}
+			// 只接受本次导航的 load 事件，忽略上一个页面迟到的事件
+			if json.Unmarshal(msg.Params, &ev) == nil && ev.Name == "load" && ev.LoaderID == nav.LoaderID {
+				return nil
+			}
+		case <-t.c.done:
+			return errClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// capture 截取当前页面的 PNG 图片
+func (t *tab) capture(ctx context.Context) ([]byte, error) {
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := t.c.call(ctx, "Page.captureScreenshot", map[string]string{"format": "png"}, &shot); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(shot.Data)
+}
+
+// unsafeChars 文件名中不允许的字符
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileName 由url生成截图文件名，加入url的哈希避免不同路径的截图互相覆盖
+func fileName(urlStr string) string {
+	name := urlStr
+	if u, err := url.Parse(urlStr); err == nil && u.Host != "" {
+		name = u.Scheme + "_" + u.Host
+	}
+	name = strings.Trim(unsafeChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	sum := sha1.Sum([]byte(urlStr))
+	return name + "_" + hex.EncodeToString(sum[:4]) + ".png"
+}
+
+// Close 关闭浏览器并删除临时用户目录
+func (p *Pool) Close() error {
+	if p.browser != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		p.browser.call(ctx, "Browser.close", nil, nil)
+		cancel()
+		p.browser.close()
+	}
+	for len(p.tabs) > 0 {
+		t := <-p.tabs
+		t.c.close()
+	}
+
+	if p.cmd != nil && p.cmd.Process != nil {
+		exited := make(chan struct{})
+		go func() {
+			p.cmd.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			p.cmd.Process.Kill()
+			<-exited
+		}
+	}
+	return os.RemoveAll(p.dataDir)
+}
@@ -0,0 +1,213 @@
+package screenshot
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		url    string
+		prefix string
+	}{
+		{"https://example.com/", "https_example.com_"},
+		{"http://example.com:8080/login?x=1", "http_example.com_8080_"},
+		{"http://[::1]:8443/", "http__1_8443_"},
+		{"http://例子.com/", "http__.com_"},
+		{"not a url", "not_a_url_"},
+	}
+	for _, tt := range tests {
+		name := fileName(tt.url)
+		if !strings.HasPrefix(name, tt.prefix) || !strings.HasSuffix(name, ".png") || len(name) != len(tt.prefix)+8+4 {
+			t.Errorf("fileName(%s) = %s, want %s + 8 hex chars + .png", tt.url, name, tt.prefix)
+		}
+		if strings.ContainsAny(name, `/\:?*"<>|`) {
+			t.Errorf("fileName(%s) = %s contains unsafe characters", tt.url, name)
+		}
+	}
+
+	// 同一主机不同路径的截图不能互相覆盖，同一url的文件名不变
+	if fileName("http://a/x") == fileName("http://a/y") {
+		t.Error("different paths should get different file names")
+	}
+	if fileName("http://a/x") != fileName("http://a/x") {
+		t.Error("file name should be stable")
+	}
+
+	long := fileName("http://" + strings.Repeat("a", 300) + ".com/")
+	if len(long) != 100+1+8+4 {
+		t.Errorf("long host: got %d characters", len(long))
+	}
+}
+
+// fakeBrowser 模拟浏览器的 DevTools 接口。url 中含 slow 时不发送 load 事件，
+// 含 fail 时导航失败，含 kill 时断开标签页的连接
+type fakeBrowser struct {
+	srv     *httptest.Server
+	targets int32 // 已创建的标签页数
+	refuse  int32 // 为1时拒绝新建标签页
+}
+
+func newFakeBrowser(t *testing.T) *fakeBrowser {
+	b := &fakeBrowser{}
+	b.srv = httptest.NewServer(websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			// 只接受 --remote-allow-origins 允许的 Origin
+			if origin := r.Header.Get("Origin"); origin != devtoolsOrigin {
+				return fmt.Errorf("origin %s not allowed", origin)
+			}
+			return nil
+		},
+		Handler: b.serve,
+	})
+	t.Cleanup(b.srv.Close)
+	return b
+}
+
+func (b *fakeBrowser) wsURL() string {
+	return "ws" + strings.TrimPrefix(b.srv.URL, "http") + "/devtools/browser/fake"
+}
+
+func (b *fakeBrowser) serve(ws *websocket.Conn) {
+	var mu sync.Mutex
+	send := func(v interface{}) {
+		mu.Lock()
+		websocket.JSON.Send(ws, v)
+		mu.Unlock()
+	}
+	for {
+		var m struct {
+			ID     int64                  `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			return
+		}
+		switch m.Method {
+		case "Target.createTarget":
+			if atomic.LoadInt32(&b.refuse) == 1 {
+				send(map[string]interface{}{"id": m.ID, "error": map[string]interface{}{"code": -1, "message": "refused"}})
+				continue
+			}
+			id := fmt.Sprintf("T%d", atomic.AddInt32(&b.targets, 1))
+			send(map[string]interface{}{"id": m.ID, "result": map[string]string{"targetId": id}})
+		case "Page.navigate":
+			u, _ := m.Params["url"].(string)
+			switch {
+			case strings.Contains(u, "kill"):
+				ws.Close()
+				return
+			case strings.Contains(u, "fail"):
+				send(map[string]interface{}{"id": m.ID, "result": map[string]string{"loaderId": "L", "errorText": "net::ERR_CONNECTION_REFUSED"}})
+				continue
+			}
+			loader := fmt.Sprint(time.Now().UnixNano())
+			send(map[string]interface{}{"id": m.ID, "result": map[string]string{"loaderId": loader}})
+			if !strings.Contains(u, "slow") {
+				send(map[string]interface{}{"method": "Page.lifecycleEvent", "params": map[string]string{"loaderId": loader, "name": "load"}})
+			}
+		case "Page.captureScreenshot":
+			send(map[string]interface{}{"id": m.ID, "result": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("png"))}})
+		default:
+			send(map[string]interface{}{"id": m.ID, "result": map[string]string{}})
+		}
+	}
+}
+
+// newTestPool 连接模拟浏览器的标签页池
+func newTestPool(t *testing.T, b *fakeBrowser, tabs int) *Pool {
+	p := &Pool{
+		tabs:    make(chan *tab, tabs),
+		noTabs:  make(chan struct{}),
+		timeout: 100 * time.Millisecond,
+		dir:     filepath.Join(t.TempDir(), Dir),
+	}
+	if err := p.connect(b.wsURL(), tabs); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestCapture(t *testing.T) {
+	b := newFakeBrowser(t)
+	p := newTestPool(t, b, 1)
+	ctx := context.Background()
+
+	name, err := p.Capture(ctx, "http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(p.dir), name))
+	if err != nil || string(data) != "png" {
+		t.Errorf("screenshot %s: %q %v", name, data, err)
+	}
+
+	// 出错、超时或取消后标签页都要放回池中，池中只有一个标签页，后续截图不能阻塞
+	if _, err := p.Capture(ctx, "http://fail/"); err == nil {
+		t.Error("navigation error should be returned")
+	}
+	if _, err := p.Capture(ctx, "http://slow/"); err != nil {
+		t.Errorf("page load timeout should still capture: %v", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := p.Capture(cancelled, "http://example.com/"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled capture: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, err := p.Capture(waitCtx, "http://example.com/"); err != nil {
+		t.Errorf("tab was not returned to the pool: %v", err)
+	}
+}
+
+func TestCaptureReplacesClosedTab(t *testing.T) {
+	b := newFakeBrowser(t)
+	p := newTestPool(t, b, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// 标签页的连接断开后换成新的标签页
+	if _, err := p.Capture(ctx, "http://kill/"); err == nil {
+		t.Error("capture on a closed tab should fail")
+	}
+	if _, err := p.Capture(ctx, "http://example.com/"); err != nil {
+		t.Errorf("closed tab was not replaced: %v", err)
+	}
+	if n := atomic.LoadInt32(&b.targets); n != 2 {
+		t.Errorf("got %d tabs, want 2", n)
+	}
+
+	// 无法新建标签页时从池中移除，全部移除后截图立即失败而不是一直等待
+	atomic.StoreInt32(&b.refuse, 1)
+	p.Capture(ctx, "http://kill/")
+	if _, err := p.Capture(ctx, "http://example.com/"); err != errNoTabs {
+		t.Errorf("got %v, want errNoTabs", err)
+	}
+}
+
+func TestDialOrigin(t *testing.T) {
+	b := newFakeBrowser(t)
+	if _, err := websocket.Dial(b.wsURL(), "", "http://evil.example"); err == nil {
+		t.Error("fake browser should reject other origins")
+	}
+	c, err := dial(b.wsURL())
+	if err != nil {
+		t.Fatalf("dial with the allowed origin: %v", err)
+	}
+	c.close()
+}
@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"bufio"
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/utils"
+	"io"
+	"os"
+	"strings"
+)
+
+// Checkpoint 断点文件，每完成一个目标记录一行，用于中断后续扫。
+// 不放入 Multi，只应在结果成功写入所有输出后调用 Write，否则续扫时会跳过未保存结果的目标。
+// 结果已写入输出、尚未记录断点时程序崩溃，续扫会重新扫描该目标，结果文件中会出现重复的行；
+// 这是有意的取舍：宁可重复也不丢失结果。
+type Checkpoint struct {
+	file *os.File
+}
+
+// NewCheckpoint 创建断点文件，appendMode 为 true 时追加到已有文件
+func NewCheckpoint(path string, appendMode bool) (*Checkpoint, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if appendMode {
+		if err := utils.TrimPartialLine(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return &Checkpoint{file: file}, nil
+}
+
+// Write 记录已完成的目标，不经过缓冲直接写入文件
+func (s *Checkpoint) Write(a *fingerprint.Fingers) error {
+	target := a.Target
+	if target == "" {
+		target = a.Url
+	}
+	_, err := s.file.WriteString(target + "\n")
+	return err
+}
+
+func (s *Checkpoint) Close() error {
+	return s.file.Close()
+}
+
+// LoadCheckpoint 读取断点文件中已完成的目标，文件不存在时返回空集合。
+// 没有换行结尾的最后一行是崩溃时写了一半的记录，不计入已完成的目标
+func LoadCheckpoint(path string) (map[string]struct{}, error) {
+	done := make(map[string]struct{})
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return done, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			done[line] = struct{}{}
+		}
+	}
+}
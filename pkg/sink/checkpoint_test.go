@@ -0,0 +1,69 @@
+package sink
+
+import (
+	"httpgo/pkg/fingerprint"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.checkpoint")
+
+	// 文件不存在时没有已完成的目标
+	done, err := LoadCheckpoint(path)
+	if err != nil || len(done) != 0 {
+		t.Fatalf("missing file: %v %v", done, err)
+	}
+
+	c, err := NewCheckpoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Write(&fingerprint.Fingers{Target: "example.com:8443", Url: "https://example.com:8443"})
+	c.Write(&fingerprint.Fingers{Url: "http://b"})
+	// Write 不经过缓冲，关闭前即可读到
+	done, err = LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct{}{"example.com:8443": {}, "http://b": {}}
+	if !reflect.DeepEqual(done, want) {
+		t.Errorf("got %v, want %v", done, want)
+	}
+	c.Close()
+
+	// 崩溃时最后一行只写了一半：读取时忽略，续扫追加前截掉
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString("http://exa")
+	f.Close()
+	done, err = LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(done, want) {
+		t.Errorf("truncated line: got %v, want %v", done, want)
+	}
+
+	c, err = NewCheckpoint(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Write(&fingerprint.Fingers{Url: "http://c"})
+	c.Close()
+	data, _ := os.ReadFile(path)
+	if string(data) != "example.com:8443\nhttp://b\nhttp://c\n" {
+		t.Errorf("after resume: %q", data)
+	}
+
+	// 不续扫时清空已有记录
+	c, err = NewCheckpoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if done, _ := LoadCheckpoint(path); len(done) != 0 {
+		t.Errorf("new scan: got %v", done)
+	}
+}
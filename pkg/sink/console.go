@@ -0,0 +1,47 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/utils"
+	"io"
+)
+
+// 终端颜色
+const (
+	reset = "\033[0m"
+	green = "\033[32m"
+	red   = "\033[31m"
+)
+
+// Console 将结果输出到终端，JSON 为 true 时每条结果输出一行 JSON，字段与 json/jsonl 文件相同
+type Console struct {
+	w       io.Writer
+	json    bool
+	encoder *json.Encoder
+}
+
+// NewConsole 创建终端输出，表格模式下先输出表头
+func NewConsole(w io.Writer, jsonLines bool) *Console {
+	s := &Console{w: w, json: jsonLines}
+	if jsonLines {
+		s.encoder = json.NewEncoder(w)
+		s.encoder.SetEscapeHTML(false)
+	} else {
+		fmt.Fprintf(w, "%-40s %-10s %-30s %-10s %-10s\n", "URL", "Status", "Title", "CMSList", "OtherList")
+	}
+	return s
+}
+
+func (s *Console) Write(a *fingerprint.Fingers) error {
+	if s.json {
+		return s.encoder.Encode(Record(a))
+	}
+	_, err := fmt.Fprintf(s.w, "%-40s %-10d %-30s %s%-10s%s %s%-10s%s\n", a.Url, a.StatusCode, a.Title, green, utils.FormatCmsList(a.CmsList), reset, red, utils.FormatCmsList(a.OtherList), reset)
+	return err
+}
+
+func (s *Console) Close() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package sink
+
+import (
+	"encoding/csv"
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/utils"
+	"os"
+	"strconv"
+)
+
+// CSVHeader CSV 表头。Url 列为输入的原始目标，自动选择协议后实际请求的地址在最后的 RequestUrl 列
+var CSVHeader = []string{"Url", "StatusCode", "Title", "CmsList", "OtherList", "Extracted", "FinalUrl", "RedirectChain", "ErrorClass", "Error", "DNSMs", "ConnectMs", "TLSMs", "TTFBMs", "TotalMs",
+	"TLSVersion", "TLSCipher", "CertCN", "CertSAN", "CertIssuer", "CertNotAfter", "CertSHA256", "JARM", "TLSIssues", "Protocol", "AltSvc", "RequestUrl"}
+
+// CSV 将结果写入 CSV 文件
+type CSV struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSV 创建 CSV 文件并写入表头，appendMode 为 true 时追加到已有文件（文件为空时才写表头）
+func NewCSV(path string, appendMode bool) (*CSV, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if appendMode {
+		if err := utils.TrimPartialLine(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s := &CSV{file: file, writer: csv.NewWriter(file)}
+	if info.Size() == 0 {
+		if err := s.writer.Write(CSVHeader); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Write 每行写入后立即落盘，与断点文件保持一致
+func (s *CSV) Write(a *fingerprint.Fingers) error {
+	r := Record(a)
+	if err := s.writer.Write([]string{r.Target, strconv.Itoa(r.StatusCode), r.Title, r.CmsList, r.OtherList, r.Extracted, r.FinalUrl, r.RedirectChain, r.ErrorClass, r.Error,
+		formatMs(r.DNSMs), formatMs(r.ConnectMs), formatMs(r.TLSMs), formatMs(r.TTFBMs), formatMs(r.TotalMs),
+		r.TLSVersion, r.TLSCipher, r.CertCN, r.CertSAN, r.CertIssuer, r.CertNotAfter, r.CertSHA256, r.JARM, r.TLSIssues, r.Protocol, r.AltSvc, r.Url}); err != nil {
+		return err
+	}
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// formatMs 毫秒数转为字符串
+func formatMs(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func (s *CSV) Close() error {
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,29 @@
+package sink
+
+import (
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/utils"
+	"os"
+)
+
+// HTML 生成 HTML 报告页面，页面数据从同目录下的 JSON 文件读取（由 JSON 输出生成）
+type HTML struct {
+	file *os.File
+}
+
+// NewHTML 创建 HTML 报告，jsonName 为页面读取的 JSON 文件名
+func NewHTML(path string, jsonName string) (*HTML, error) {
+	file, err := utils.InitializeHTMLReport(path, jsonName)
+	if err != nil {
+		return nil, err
+	}
+	return &HTML{file: file}, nil
+}
+
+func (s *HTML) Write(a *fingerprint.Fingers) error {
+	return nil
+}
+
+func (s *HTML) Close() error {
+	return s.file.Close()
+}
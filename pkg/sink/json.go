@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/utils"
+	"os"
+	"strings"
+	"time"
+)
+
+// JSONL 将结果以 JSON lines 格式追加到文件
+type JSONL struct {
+	w *utils.JSONLWriter
+}
+
+// NewJSONL 创建 JSONL 文件，appendMode 为 true 时追加到已有文件
+func NewJSONL(path string, appendMode bool) (*JSONL, error) {
+	w, err := utils.NewJSONLWriter(path, "", 0, appendMode)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONL{w: w}, nil
+}
+
+func (s *JSONL) Write(a *fingerprint.Fingers) error {
+	return s.w.Write(Record(a))
+}
+
+func (s *JSONL) Close() error {
+	return s.w.Close()
+}
+
+// JSON 生成 HTML 报告读取的 JSON 数组文件。
+// 扫描过程中结果先逐行写入同名的 .jsonl 文件，关闭时（以及每隔 refresh）再转换为数组文件。
+type JSON struct {
+	w         *utils.JSONLWriter
+	jsonlPath string
+	keepJSONL bool
+}
+
+// NewJSON 创建 JSON 文件，keepJSONL 为 false 时关闭后删除中间的 .jsonl 文件。
+// appendMode 为 true 时保留已有结果，新结果追加在后面。
+func NewJSON(path string, keepJSONL bool, refresh time.Duration, appendMode bool) (*JSON, error) {
+	jsonlPath := strings.TrimSuffix(path, ".json") + ".jsonl"
+	if appendMode {
+		// 上次未保留 .jsonl 文件时，由已有的 .json 文件还原
+		if _, err := os.Stat(jsonlPath); os.IsNotExist(err) {
+			if _, err := os.Stat(path); err == nil {
+				if err := utils.JSONReportToLines(path, jsonlPath); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	w, err := utils.NewJSONLWriter(jsonlPath, path, refresh, appendMode)
+	if err != nil {
+		return nil, err
+	}
+	return &JSON{w: w, jsonlPath: jsonlPath, keepJSONL: keepJSONL}, nil
+}
+
+func (s *JSON) Write(a *fingerprint.Fingers) error {
+	return s.w.Write(Record(a))
+}
+
+func (s *JSON) Close() error {
+	err := s.w.Close()
+	if err == nil && !s.keepJSONL {
+		err = os.Remove(s.jsonlPath)
+	}
+	return err
+}
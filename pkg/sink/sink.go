@@ -0,0 +1,170 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sink 扫描结果的输出目标。Write 只会被同一个goroutine调用，实现无需加锁
+type Sink interface {
+	Write(*fingerprint.Fingers) error
+	Close() error
+}
+
+// Multi 将每条结果依次写入多个 Sink
+type Multi []Sink
+
+func (m Multi) Write(a *fingerprint.Fingers) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Write(a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m Multi) Close() error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 文件输出格式
+const (
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatHTML  = "html"
+)
+
+// ParseFormats 解析 -o 参数，如 csv,jsonl,html
+func ParseFormats(s string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		switch f {
+		case FormatCSV, FormatJSON, FormatJSONL, FormatHTML:
+		default:
+			return nil, fmt.Errorf("invalid output format '%s', expected csv, json, jsonl or html", f)
+		}
+		seen[f] = true
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+
+// FileOptions 文件输出配置
+type FileOptions struct {
+	Dir     string        // 输出目录
+	Name    string        // 文件名（不含后缀）
+	Refresh time.Duration // 大于0时定时刷新 json 文件，以便扫描过程中实时查看 HTML 报告
+	Append  bool          // 追加到已有文件（续扫），否则覆盖
+}
+
+// OpenFiles 按格式在输出目录中创建文件 Sink。html 报告读取同名的 json 文件，选择 html 时会同时生成 json
+func OpenFiles(formats []string, opts FileOptions) (Multi, error) {
+	if err := os.MkdirAll(opts.Dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	path := func(ext string) string {
+		return filepath.Join(opts.Dir, opts.Name+"."+ext)
+	}
+
+	want := make(map[string]bool)
+	for _, f := range formats {
+		want[f] = true
+	}
+
+	var sinks Multi
+	fail := func(err error) (Multi, error) {
+		sinks.Close()
+		return nil, err
+	}
+
+	if want[FormatCSV] {
+		s, err := NewCSV(path(FormatCSV), opts.Append)
+		if err != nil {
+			return fail(err)
+		}
+		sinks = append(sinks, s)
+	}
+	if want[FormatJSON] || want[FormatHTML] {
+		s, err := NewJSON(path(FormatJSON), want[FormatJSONL], opts.Refresh, opts.Append)
+		if err != nil {
+			return fail(err)
+		}
+		sinks = append(sinks, s)
+	} else if want[FormatJSONL] {
+		s, err := NewJSONL(path(FormatJSONL), opts.Append)
+		if err != nil {
+			return fail(err)
+		}
+		sinks = append(sinks, s)
+	}
+	if want[FormatHTML] {
+		s, err := NewHTML(path(FormatHTML), opts.Name+"."+FormatJSON)
+		if err != nil {
+			return fail(err)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// Record 将结果转换为报告中的一行，列表字段以 ; 连接
+func Record(a *fingerprint.Fingers) utils.URLFingerprint {
+	r := utils.URLFingerprint{
+		Target:        a.Target,
+		Url:           a.Url,
+		StatusCode:    a.StatusCode,
+		Title:         a.Title,
+		CmsList:       strings.Join(a.CmsList, ";"),
+		OtherList:     strings.Join(a.OtherList, ";"),
+		Screenshot:    a.Screenshot,
+		Extracted:     utils.FormatExtracted(a.Extracted),
+		FinalUrl:      a.FinalUrl,
+		RedirectChain: httpgo.FormatRedirects(a.Redirects, a.FinalUrl),
+		ErrorClass:    a.ErrorClass,
+		Error:         a.Error,
+		DNSMs:         a.Timing.DNS.Milliseconds(),
+		ConnectMs:     a.Timing.Connect.Milliseconds(),
+		TLSMs:         a.Timing.TLS.Milliseconds(),
+		TTFBMs:        a.Timing.TTFB.Milliseconds(),
+		TotalMs:       a.Timing.Total.Milliseconds(),
+		JARM:          a.JARM,
+		TLSIssues:     httpgo.FormatTLSIssues(a.TLSIssues),
+		Protocol:      a.Protocol,
+		AltSvc:        strings.Join(a.AltSvc, ";"),
+	}
+	if r.Target == "" {
+		r.Target = a.Url
+	}
+	if a.TLS != nil {
+		r.TLSVersion = a.TLS.Version
+		r.TLSCipher = a.TLS.CipherSuite
+	}
+	if leaf := a.TLS.Leaf(); leaf != nil {
+		r.CertCN = leaf.CommonName
+		r.CertSAN = strings.Join(leaf.SANs, ";")
+		r.CertIssuer = leaf.Issuer
+		r.CertNotAfter = leaf.NotAfter.Format("2006-01-02 15:04:05")
+		r.CertSHA256 = leaf.SHA256
+	}
+	return r
+}
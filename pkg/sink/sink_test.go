@@ -0,0 +1,232 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"httpgo/pkg/fingerprint"
+	"httpgo/pkg/httpgo"
+	"httpgo/pkg/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFingers 不带协议的目标自动选择协议后的结果
+func testFingers() *fingerprint.Fingers {
+	return &fingerprint.Fingers{
+		Target:     "example.com:8443",
+		Url:        "https://example.com:8443",
+		StatusCode: 200,
+		Title:      "登录, \"admin\"",
+		CmsList:    []string{"nginx", "php"},
+		FinalUrl:   "https://example.com:8443/login",
+		Redirects:  []httpgo.RedirectHop{{Url: "https://example.com:8443", StatusCode: 302, Location: "/login", Kind: httpgo.RedirectHTTP}},
+		Timing:     httpgo.Timing{Total: 1500 * time.Millisecond},
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "csv,json,jsonl,html", want: []string{"csv", "json", "jsonl", "html"}},
+		{in: " CSV , jsonl,csv,", want: []string{"csv", "jsonl"}},
+		{in: "", want: nil},
+		{in: "csv,xml", wantErr: true},
+		{in: "json;html", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormats(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFormats(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFormats(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// fakeSink 记录写入次数，err 不为 nil 时每次写入和关闭都返回该错误
+type fakeSink struct {
+	writes, closes int
+	err            error
+}
+
+func (s *fakeSink) Write(a *fingerprint.Fingers) error {
+	s.writes++
+	return s.err
+}
+
+func (s *fakeSink) Close() error {
+	s.closes++
+	return s.err
+}
+
+func TestMulti(t *testing.T) {
+	errA, errB := errors.New("disk full"), errors.New("closed")
+	a, ok, b := &fakeSink{err: errA}, &fakeSink{}, &fakeSink{err: errB}
+	m := Multi{a, ok, b}
+
+	// 某个输出失败时其余输出仍然写入，返回所有错误
+	err := m.Write(testFingers())
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Write: got %v, want both errors", err)
+	}
+	err = m.Close()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Close: got %v, want both errors", err)
+	}
+	for i, s := range []*fakeSink{a, ok, b} {
+		if s.writes != 1 || s.closes != 1 {
+			t.Errorf("sink %d: %d writes, %d closes", i, s.writes, s.closes)
+		}
+	}
+
+	if err := (Multi{ok}).Write(testFingers()); err != nil {
+		t.Errorf("no errors: got %v", err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.csv")
+	s, err := NewCSV(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(testFingers()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 追加时不重复写表头
+	s, err = NewCSV(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(&fingerprint.Fingers{Url: "http://b", StatusCode: -1, ErrorClass: httpgo.ErrorRefused})
+	s.Close()
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[0], CSVHeader) {
+		t.Fatalf("got %d rows, header %v", len(rows), rows[0])
+	}
+	col := func(row []string, name string) string {
+		for i, h := range CSVHeader {
+			if h == name {
+				return row[i]
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return ""
+	}
+
+	want := map[string]string{
+		"Url":           "example.com:8443",
+		"RequestUrl":    "https://example.com:8443",
+		"StatusCode":    "200",
+		"Title":         "登录, \"admin\"",
+		"CmsList":       "nginx;php",
+		"FinalUrl":      "https://example.com:8443/login",
+		"RedirectChain": "https://example.com:8443 -[302]-> https://example.com:8443/login",
+		"TotalMs":       "1500",
+	}
+	for name, v := range want {
+		if got := col(rows[1], name); got != v {
+			t.Errorf("%s = %q, want %q", name, got, v)
+		}
+	}
+	// 没有原始目标时 Url 列为请求地址
+	if col(rows[2], "Url") != "http://b" || col(rows[2], "ErrorClass") != "refused" {
+		t.Errorf("second row: %v", rows[2])
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.jsonl")
+	s, err := NewJSONL(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(testFingers())
+	s.Write(&fingerprint.Fingers{Url: "http://b"})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), data)
+	}
+	var r utils.URLFingerprint
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, Record(testFingers())) {
+		t.Errorf("got %+v, want %+v", r, Record(testFingers()))
+	}
+}
+
+func TestConsoleJSON(t *testing.T) {
+	// -json 输出的字段与 json/jsonl 文件相同
+	var buf bytes.Buffer
+	s := NewConsole(&buf, true)
+	s.Write(testFingers())
+	s.Write(&fingerprint.Fingers{Url: "http://b"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	var r utils.URLFingerprint
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, Record(testFingers())) {
+		t.Errorf("got %+v, want %+v", r, Record(testFingers()))
+	}
+	if !strings.Contains(lines[0], `"Target":"example.com:8443"`) {
+		t.Errorf("unexpected line: %s", lines[0])
+	}
+
+	// 表格模式不输出 JSON
+	buf.Reset()
+	NewConsole(&buf, false).Write(testFingers())
+	if strings.HasPrefix(buf.String(), "{") || !strings.Contains(buf.String(), "https://example.com:8443") {
+		t.Errorf("table output: %s", buf.String())
+	}
+}
+
+func TestOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	sinks, err := OpenFiles([]string{FormatCSV, FormatHTML}, FileOptions{Dir: dir, Name: "r"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sinks.Write(testFingers())
+	if err := sinks.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// html 依赖 json；未选择 jsonl 时删除中间文件
+	for name, want := range map[string]bool{"r.csv": true, "r.json": true, "r.html": true, "r.jsonl": false} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+}
@@ -13,6 +13,12 @@ type FingerprintFile struct {
 	Name    string `json:"name"`
 	Type    string `json:"type"`
 	Keyword string `json:"keyword"`
+
+	// 以下为可选项，设置 path 后 keyword 将匹配该路径的响应
+	Path    string            `json:"path,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
 }
 
 // 获取指纹规则
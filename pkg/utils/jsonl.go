@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONLWriter 将结果以 JSON lines 格式追加到文件，所有写入由同一个goroutine完成。
+// Close 时把 JSONL 文件转换为 HTML 报告读取的 JSON 数组文件。
+type JSONLWriter struct {
+	jsonlPath string
+	jsonPath  string
+
+	lines chan jsonLine
+	done  chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// jsonLine 一条待写入的记录，写入文件后通过 written 返回结果
+type jsonLine struct {
+	data    []byte
+	written chan error
+}
+
+// NewJSONLWriter 创建 JSONL 写入器，jsonPath 为空时不生成数组文件。
+// refresh 大于0时每隔 refresh 重新生成一次数组文件，以便扫描过程中实时查看 HTML 报告。
+// appendMode 为 true 时追加到已有的 JSONL 文件（续扫）。
+func NewJSONLWriter(jsonlPath string, jsonPath string, refresh time.Duration, appendMode bool) (*JSONLWriter, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(jsonlPath, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if appendMode {
+		if err := TrimPartialLine(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	// 先生成空数组，HTML 报告在第一次刷新前也能正常打开
+	if jsonPath != "" {
+		if err := FinalizeJSONReport(jsonlPath, jsonPath); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	w := &JSONLWriter{
+		jsonlPath: jsonlPath,
+		jsonPath:  jsonPath,
+		lines:     make(chan jsonLine, 256),
+		done:      make(chan struct{}),
+	}
+	go w.run(file, refresh)
+	return w, nil
+}
+
+// run 写入goroutine，独占文件。每条记录直接写入文件后才通知 Write 返回，
+// 调用方据此在记录落盘后再更新续扫进度
+func (w *JSONLWriter) run(file *os.File, refresh time.Duration) {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	if refresh > 0 && w.jsonPath != "" {
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	dirty := false
+	for {
+		select {
+		case line, ok := <-w.lines:
+			if !ok {
+				if err := file.Close(); err != nil {
+					w.setErr(err)
+				}
+				return
+			}
+			if _, err := file.Write(line.data); err != nil {
+				w.setErr(err)
+			}
+			line.written <- w.Err()
+			dirty = true
+		case <-tick:
+			if !dirty {
+				continue
+			}
+			if err := FinalizeJSONReport(w.jsonlPath, w.jsonPath); err != nil {
+				w.setErr(err)
+			}
+			dirty = false
+		}
+	}
+}
+
+func (w *JSONLWriter) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Err 返回写入过程中遇到的第一个错误
+func (w *JSONLWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Write 编码一条记录并交给写入goroutine，记录写入文件后才返回。
+// 可被多个goroutine并发调用，不能在 Close 之后调用
+func (w *JSONLWriter) Write(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	written := make(chan error, 1)
+	w.lines <- jsonLine{data: append(line, '\n'), written: written}
+	return <-written
+}
+
+// Close 写完剩余记录并生成 JSON 数组文件
+func (w *JSONLWriter) Close() error {
+	close(w.lines)
+	<-w.done
+	if err := w.Err(); err != nil {
+		return err
+	}
+	if w.jsonPath == "" {
+		return nil
+	}
+	return FinalizeJSONReport(w.jsonlPath, w.jsonPath)
+}
+
+// TrimPartialLine 截掉文件末尾不完整的一行（上次写入时程序崩溃），
+// 避免追加的记录与其拼接成无效的一行
+func TrimPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	end := info.Size()
+	chunk := make([]byte, 4096)
+	for pos := end; pos > 0; {
+		n := int64(len(chunk))
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		if _, err := file.ReadAt(chunk[:n], pos); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(chunk[:n], '\n'); i >= 0 {
+			if pos+int64(i)+1 == end {
+				return nil
+			}
+			return file.Truncate(pos + int64(i) + 1)
+		}
+	}
+	if end == 0 {
+		return nil
+	}
+	return file.Truncate(0)
+}
+
+// FinalizeJSONReport 将 JSONL 文件逐行转换为 JSON 数组文件（先写临时文件再替换，读取方不会看到写了一半的文件）
+func FinalizeJSONReport(jsonlPath string, jsonPath string) error {
+	in, err := os.Open(jsonlPath)
+	if err != nil {
+		return fmt.Errorf("无法读取 JSONL 文件: %v", err)
+	}
+	defer in.Close()
+
+	tmpPath := jsonPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("无法创建 JSON 文件: %v", err)
+	}
+	buf := bufio.NewWriterSize(out, 64*1024)
+
+	reader := bufio.NewReaderSize(in, 64*1024)
+	var indented bytes.Buffer
+	count := 0
+	buf.WriteString("[")
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			out.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("无法读取 JSONL 文件: %v", err)
+		}
+		partial := err == io.EOF
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if partial {
+				break
+			}
+			continue
+		}
+		indented.Reset()
+		if err := json.Indent(&indented, line, "    ", "    "); err != nil {
+			if partial {
+				// 没有换行结尾的最后一行是崩溃时写了一半的记录，忽略
+				break
+			}
+			out.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("无法解码 JSON 内容: %v", err)
+		}
+		if count > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n    ")
+		buf.Write(indented.Bytes())
+		count++
+		if partial {
+			break
+		}
+	}
+	if count > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+
+	if err := buf.Flush(); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("无法写入 JSON 数据: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("无法写入 JSON 数据: %v", err)
+	}
+	return os.Rename(tmpPath, jsonPath)
+}
+
+// JSONReportToLines 将 JSON 数组文件逐条转换为 JSONL 文件，用于在只保留了 .json 文件时续扫
+func JSONReportToLines(jsonPath string, jsonlPath string) error {
+	in, err := os.Open(jsonPath)
+	if err != nil {
+		return fmt.Errorf("无法读取 JSON 文件: %v", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(jsonlPath)
+	if err != nil {
+		return fmt.Errorf("无法创建 JSONL 文件: %v", err)
+	}
+	buf := bufio.NewWriterSize(out, 64*1024)
+
+	dec := json.NewDecoder(bufio.NewReader(in))
+	if _, err := dec.Token(); err == io.EOF {
+		// 空文件视为没有结果
+		return out.Close()
+	} else if err != nil {
+		out.Close()
+		return fmt.Errorf("无法解码 JSON 内容: %v", err)
+	}
+	var compact bytes.Buffer
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			out.Close()
+			return fmt.Errorf("无法解码 JSON 内容: %v", err)
+		}
+		compact.Reset()
+		if err := json.Compact(&compact, raw); err != nil {
+			out.Close()
+			return fmt.Errorf("无法解码 JSON 内容: %v", err)
+		}
+		compact.WriteByte('\n')
+		buf.Write(compact.Bytes())
+	}
+
+	if err := buf.Flush(); err != nil {
+		out.Close()
+		return fmt.Errorf("无法写入 JSONL 数据: %v", err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type jsonlRecord struct {
+	Url   string
+	Title string
+	List  []string
+}
+
+// readJSONReport 读取 JSON 数组文件
+func readJSONReport(t *testing.T, path string) []jsonlRecord {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []jsonlRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("%s is not a JSON array: %v\n%s", path, err, data)
+	}
+	return records
+}
+
+func TestJSONLWriter(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "r.jsonl")
+	jsonPath := filepath.Join(dir, "r.json")
+
+	w, err := NewJSONLWriter(jsonlPath, jsonPath, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 创建时即生成空数组
+	if got := readJSONReport(t, jsonPath); len(got) != 0 {
+		t.Errorf("new report: got %v", got)
+	}
+
+	// Write 返回时记录已经写入文件
+	if err := w.Write(jsonlRecord{Url: "http://a", Title: "<a>"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(jsonlPath)
+	if string(data) != `{"Url":"http://a","Title":"\u003ca\u003e","List":null}`+"\n" {
+		t.Errorf("got %q after the first Write", data)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := w.Write(jsonlRecord{Url: fmt.Sprintf("http://%d", i), List: []string{"x"}}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readJSONReport(t, jsonPath); len(got) != 21 || got[0].Title != "<a>" {
+		t.Errorf("got %d records: %v", len(got), got)
+	}
+
+	// 追加模式保留已有记录，并截掉崩溃时写了一半的最后一行
+	f, _ := os.OpenFile(jsonlPath, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"Url":"http://par`)
+	f.Close()
+	w, err = NewJSONLWriter(jsonlPath, jsonPath, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(jsonlRecord{Url: "http://b"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := readJSONReport(t, jsonPath)
+	if len(got) != 22 || got[21].Url != "http://b" {
+		t.Errorf("append: got %d records, last %v", len(got), got[len(got)-1])
+	}
+}
+
+func TestTrimPartialLine(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"a\n", "a\n"},
+		{"a\nb", "a\n"},
+		{"partial", ""},
+		{"a\n" + long, "a\n"},
+		{long + "\n" + long, long + "\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "f")
+		os.WriteFile(path, []byte(tt.in), 0644)
+		f, err := os.OpenFile(path, os.O_RDWR, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := TrimPartialLine(f); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if data, _ := os.ReadFile(path); string(data) != tt.want {
+			t.Errorf("TrimPartialLine(%.20q) = %.20q, want %.20q", tt.in, data, tt.want)
+		}
+	}
+}
+
+func TestJSONReportRoundTrip(t *testing.T) {
+	want := []jsonlRecord{
+		{Url: "http://a", Title: "标题", List: []string{"nginx", "php"}},
+		{Url: "http://b", Title: "line\nbreak \"quoted\""},
+	}
+	var lines strings.Builder
+	for _, r := range want {
+		b, _ := json.Marshal(r)
+		lines.Write(b)
+		lines.WriteString("\n\n")
+	}
+
+	tests := []struct {
+		name  string
+		jsonl string
+		want  []jsonlRecord
+	}{
+		{"records", lines.String(), want},
+		{"empty", "", nil},
+		// 崩溃时最后一行只写了一半，忽略这一行
+		{"partial last line", lines.String() + `{"Url":"http://c","Ti`, want},
+		{"last line without newline", strings.TrimRight(lines.String(), "\n"), want},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			jsonlPath := filepath.Join(dir, "r.jsonl")
+			jsonPath := filepath.Join(dir, "r.json")
+			os.WriteFile(jsonlPath, []byte(tt.jsonl), 0644)
+
+			if err := FinalizeJSONReport(jsonlPath, jsonPath); err != nil {
+				t.Fatal(err)
+			}
+			got := readJSONReport(t, jsonPath)
+			if len(got) == 0 && len(tt.want) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FinalizeJSONReport: got %v, want %v", got, tt.want)
+			}
+
+			// 由数组文件还原的 JSONL 再次转换后内容不变
+			restored := filepath.Join(dir, "restored.jsonl")
+			if err := JSONReportToLines(jsonPath, restored); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := os.ReadFile(restored)
+			if n := strings.Count(string(data), "\n"); n != len(tt.want) {
+				t.Errorf("JSONReportToLines: got %d lines, want %d", n, len(tt.want))
+			}
+			again := filepath.Join(dir, "again.json")
+			if err := FinalizeJSONReport(restored, again); err != nil {
+				t.Fatal(err)
+			}
+			first, _ := os.ReadFile(jsonPath)
+			second, _ := os.ReadFile(again)
+			if string(first) != string(second) {
+				t.Errorf("round trip changed the report:\n%s\n%s", first, second)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(empty, nil, 0644)
+	if err := JSONReportToLines(empty, filepath.Join(dir, "empty.jsonl")); err != nil {
+		t.Errorf("empty JSON file: %v", err)
+	}
+	bad := filepath.Join(dir, "bad.jsonl")
+	os.WriteFile(bad, []byte("{\"Url\":\n{}\n"), 0644)
+	if err := FinalizeJSONReport(bad, filepath.Join(dir, "bad.json")); err == nil {
+		t.Error("invalid line in the middle should be an error")
+	}
+	if err := JSONReportToLines(filepath.Join(dir, "missing.json"), filepath.Join(dir, "m.jsonl")); err == nil {
+		t.Error("missing file should be an error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/charset"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -84,6 +85,40 @@ func ExtractSpareFavicon(htmlContent string) ([]string, error) {
 	return fav, nil
 }
 
+// meta refresh 跳转，如 <meta http-equiv="refresh" content="0;url=/login">
+var metaRefreshRe = regexp.MustCompile(`(?is)<meta[^>]*http-equiv\s*=\s*["']?refresh["']?[^>]*>`)
+var metaContentRe = regexp.MustCompile(`(?is)content\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+var refreshURLRe = regexp.MustCompile(`(?is)^\s*\d*(?:\.\d*)?\s*[;,]?\s*url\s*=\s*["']?([^"']+)`)
+
+// JavaScript 跳转，如 window.location.href="/login" 或 location.replace('/login')
+// location 前必须是单词边界，xlocation、mylocation.href 等变量不算跳转
+var jsRedirectRe = regexp.MustCompile(`(?is)\b(?:(?:window|document|top|self)\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']|\blocation\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)`)
+
+// jsRedirectMaxBody 只有较小的页面才识别JavaScript跳转，避免把正常页面中的链接脚本当成跳转
+const jsRedirectMaxBody = 8 * 1024
+
+// ExtractHTMLRedirect 提取页面中的 meta refresh 或 JavaScript 跳转地址，kind 为 "meta" 或 "js"，未找到时返回空字符串
+func ExtractHTMLRedirect(body []byte) (location string, kind string) {
+	if tag := metaRefreshRe.Find(body); tag != nil {
+		if m := metaContentRe.FindSubmatch(tag); m != nil {
+			content := string(m[1]) + string(m[2])
+			if u := refreshURLRe.FindStringSubmatch(content); u != nil {
+				return strings.TrimSpace(u[1]), "meta"
+			}
+		}
+	}
+
+	if len(body) <= jsRedirectMaxBody {
+		if m := jsRedirectRe.FindSubmatch(body); m != nil {
+			location = string(m[1]) + string(m[2])
+			if location != "" && !strings.HasPrefix(strings.ToLower(location), "javascript:") {
+				return strings.TrimSpace(location), "js"
+			}
+		}
+	}
+	return "", ""
+}
+
 // 去除换行符
 func RemoveNewline(str string) string {
 	// 统一替换所有换行符为单一换行符
@@ -98,6 +133,31 @@ func FormatCmsList(cmsList []string) string {
 	return fmt.Sprintf("[%s]", JoinStrings(cmsList, ", "))
 }
 
+// 格式化正则提取的内容，如 【jQuery】{version=3.5.1};【nginx】{version=1.20}
+func FormatExtracted(extracted map[string]map[string]string) string {
+	names := make([]string, 0, len(extracted))
+	for name := range extracted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var items []string
+	for _, name := range names {
+		keys := make([]string, 0, len(extracted[name]))
+		for k := range extracted[name] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var pairs []string
+		for _, k := range keys {
+			pairs = append(pairs, k+"="+extracted[name][k])
+		}
+		items = append(items, name+"{"+strings.Join(pairs, ",")+"}")
+	}
+	return strings.Join(items, ";")
+}
+
 // 将字符串切片连接成单个字符串
 func JoinStrings(slice []string, separator string) string {
 	result := ""
@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestExtractHTMLRedirect(t *testing.T) {
+	tests := []struct {
+		body     string
+		location string
+		kind     string
+	}{
+		{`<meta http-equiv="refresh" content="0;url=/login">`, "/login", "meta"},
+		{`<META HTTP-EQUIV=Refresh CONTENT='5; URL=http://b/'>`, "http://b/", "meta"},
+		{`<meta http-equiv="refresh" content="30">`, "", ""},
+		{`<script>location = "/a"</script>`, "/a", "js"},
+		{`<script>window.location.href='/a';</script>`, "/a", "js"},
+		{`<script>top.location="/a"</script>`, "/a", "js"},
+		{`<script>document.location.href = "/a"</script>`, "/a", "js"},
+		{`<script>location.replace("/a")</script>`, "/a", "js"},
+		{`<script>window.location.assign( '/a' )</script>`, "/a", "js"},
+		{`<script>if(x){location.href="/a"}</script>`, "/a", "js"},
+
+		// 变量名中包含 location 的不是跳转
+		{`<script>xlocation = "/a"</script>`, "", ""},
+		{`<script>mylocation.href="/a"</script>`, "", ""},
+		{`<script>geo_location.replace("/a")</script>`, "", ""},
+		{`<script>if (location == "/a") {}</script>`, "", ""},
+		{`<script>location.href = "javascript:void(0)"</script>`, "", ""},
+		{`<p>no redirect</p>`, "", ""},
+	}
+	for _, tt := range tests {
+		location, kind := ExtractHTMLRedirect([]byte(tt.body))
+		if location != tt.location || kind != tt.kind {
+			t.Errorf("ExtractHTMLRedirect(%s) = %q %q, want %q %q", tt.body, location, kind, tt.location, tt.kind)
+		}
+	}
+}
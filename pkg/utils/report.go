@@ -1,28 +1,47 @@
 package utils
 
 import (
-	"encoding/json"
-	"fmt"
 	"os"
-	"sync"
 )
 
 // URLFingerprint 结构体表示每个 URL 的指纹信息
 type URLFingerprint struct {
-	Url        string
-	StatusCode int
-	Title      string
-	CmsList    string
-	OtherList  string
-	Screenshot string
+	Target        string // 输入的原始目标，如不带协议的 host:port
+	Url           string // 实际请求的地址
+	StatusCode    int
+	Title         string
+	CmsList       string
+	OtherList     string
+	Screenshot    string
+	Extracted     string
+	FinalUrl      string
+	RedirectChain string
+	ErrorClass    string
+	Error         string
+	DNSMs         int64 // 各阶段耗时，单位毫秒
+	ConnectMs     int64
+	TLSMs         int64
+	TTFBMs        int64
+	TotalMs       int64
+	TLSVersion    string
+	TLSCipher     string
+	CertCN        string
+	CertSAN       string
+	CertIssuer    string
+	CertNotAfter  string
+	CertSHA256    string
+	JARM          string
+	TLSIssues     string // 证书与TLS配置问题，以 ; 连接，如 cert-expired(2024-01-01);tls1.0
+	Protocol      string // 使用的协议：http/1.0、http/1.1、h2
+	AltSvc        string // Alt-Svc 通告的协议，以 ; 连接，如 h3;h3-29
 }
 
 // HTML 模板
 //var HtmlHeaderA = "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n    <meta charset=\"UTF-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n    <title>httpgo Fingerprint Report</title>\n    <style>\n        body {\n            font-family: Arial, sans-serif;\n            margin: 0;\n            padding: 0;\n            background-color: #f4f4f4;\n            color: #333;\n        }\n        h1 {\n            text-align: center;\n            margin: 20px 0;\n            color: #444;\n        }\n        table {\n            width: 90%;\n            margin: 20px auto;\n            border-collapse: collapse;\n            background: #fff;\n            box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);\n        }\n        table, th, td {\n            border: 1px solid #ddd;\n        }\n        th, td {\n            padding: 12px;\n            text-align: left;\n        }\n        th {\n            background-color: #f8f8f8;\n            color: #555;\n        }\n        .container {\n            display: flex;\n            justify-content: space-between;\n            align-items: flex-start;\n            padding: 10px;\n        }\n        .left {\n            flex: 1;\n            margin-right: 20px;\n            background: #fafafa;\n            padding: 15px;\n            border-radius: 8px;\n            box-shadow: 0 2px 5px rgba(0, 0, 0, 0.1);\n            max-width: 50%;\n        }\n        .right {\n            flex: 1;\n            max-width: 50%;\n            text-align: center;\n        }\n        .right img {\n            width: 40%;\n            height: auto;\n            border-radius: 8px;\n            cursor: pointer;\n            transition: opacity 0.3s;\n        }\n        .right img:hover {\n            opacity: 0.8;\n        }\n        .modal {\n            display: none;\n            position: fixed;\n            top: 0;\n            left: 0;\n            width: 100%;\n            height: 100%;\n            background-color: rgba(0, 0, 0, 0.8);\n            align-items: center;\n            justify-content: center;\n            z-index: 1000;\n        }\n        .modal-content {\n            max-width: 90%;\n            max-height: 90%;\n            position: relative;\n        }\n        .modal-content img {\n            width: 100%;\n            height: auto;\n            border: 5px solid #fff;\n            border-radius: 8px;\n        }\n        .modal-close {\n            position: absolute;\n            top: 20px;\n            right: 20px;\n            font-size: 2rem;\n            color: #fff;\n            cursor: pointer;\n            transition: color 0.3s;\n        }\n        .modal-close:hover {\n            color: #ddd;\n        }\n        .cms-info {\n            color: red;\n        }\n        .other-info {\n            color: green;\n        }\n        .stats {\n            margin: 20px auto;\n            width: 90%;\n            padding: 15px;\n            background: #fafafa;\n            border-radius: 8px;\n            box-shadow: 0 2px 5px rgba(0, 0, 0, 0.1);\n        }\n        .stats h2 {\n            margin-top: 0;\n            font-size: 1.2rem; /* 调整大小 */\n        }\n        .stats ul {\n            list-style: none;\n            padding: 0;\n            margin: 0;\n        }\n        .stats ul li {\n            margin: 5px 0;\n            font-size: 1rem; /* 调整大小 */\n        }\n        .button-group {\n            display: flex;\n            flex-wrap: wrap;\n            /* justify-content: center; */\n            margin: 20px 0;\n        }\n        .button-group button {\n            background-color: #007bff;\n            color: white;\n            border: none;\n            padding: 6px 12px; /* 减少内边距 */\n            margin: 4px; /* 减少外边距 */\n            border-radius: 4px; /* 减小圆角 */\n            cursor: pointer;\n            transition: background-color 0.3s;\n            font-size: 0.875rem; /* 调整字体大小 */\n        }\n\n        .button-group button:hover {\n            background-color: #0056b3;\n        }\n\n        #scroll-to-top {\n            position: fixed;\n            bottom: 20px;\n            right: 20px;\n            background-color: #007bff;\n            color: white;\n            border: none;\n            border-radius: 50%;\n            width: 40px; /* 减少宽度 */\n            height: 40px; /* 减少高度 */\n            display: flex;\n            align-items: center;\n            justify-content: center;\n            cursor: pointer;\n            font-size: 18px; /* 调整字体大小 */\n            box-shadow: 0 4px 8px rgba(0, 0, 0, 0.2);\n            transition: background-color 0.3s, box-shadow 0.3s;\n        }\n        \n        #scroll-to-top:hover {\n            background-color: #0056b3;\n            box-shadow: 0 6px 12px rgba(0, 0, 0, 0.3);\n        }\n\n    </style>\n    <script>\n        document.addEventListener(\"DOMContentLoaded\", function() {\n        const scrollToTopButton = document.getElementById(\"scroll-to-top\");\n                \n        scrollToTopButton.addEventListener(\"click\", function() {\n            window.scrollTo({\n                top: 0,\n                behavior: \"smooth\"\n            });\n        });\n        \n        // Show or hide the button based on scroll position\n        window.addEventListener(\"scroll\", function() {\n            if (window.scrollY > 300) {\n                scrollToTopButton.style.display = \"flex\";\n            } else {\n                scrollToTopButton.style.display = \"none\";\n            }\n        });\n        });\n\n        document.addEventListener(\"DOMContentLoaded\", function() {\n            let originalData = [];\n\n            function openModal(src) {\n                var modal = document.getElementById(\"modal\");\n                var modalImg = document.getElementById(\"modal-img\");\n                modal.style.display = \"flex\";\n                modalImg.src = src;\n            }\n\n            function closeModal(event) {\n                if (event.target === document.getElementById(\"modal\")) {\n                    document.getElementById(\"modal\").style.display = \"none\";\n                }\n            }\n\n            function updateStats(data) {\n                const cmsCount = {};\n                const otherCount = {};\n\n                data.forEach(item => {\n                    item.CmsList.split(';').forEach(cms => {\n                        cms = cms.trim();\n                        if (cms) {\n                            cmsCount[cms] = (cmsCount[cms] || 0) + 1;\n                        }\n                    });\n\n                    item.OtherList.split(';').forEach(other => {\n                        other = other.trim();\n                        if (other) {\n                            otherCount[other] = (otherCount[other] || 0) + 1;\n                        }\n                    });\n                });\n\n                const cmsStats = Object.entries(cmsCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"cms-item\" data-type=\"cms\" data-value=\"${key}\">${key}: ${value}</button>`)\n                    .join(”);\n                document.getElementById('cms-stats').innerHTML = `<h2>CMS Fingerprint Information</h2><div class=\"button-group\">${cmsStats}</div>`;\n\n                const otherStats = Object.entries(otherCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"other-item\" data-type=\"other\" data-value=\"${key}\">${key}: ${value}</button>`)\n                    .join(”);\n                document.getElementById('other-stats').innerHTML = `<br><h2>OTHER Fingerprint Information</h2><div class=\"button-group\">${otherStats}</div>`;\n\n                document.getElementById('all-stats').innerHTML = `<br><h2>All Fingerprint Information</h2><div class=\"button-group\"><button id=\"btn-all\">ALL</button></div>`;\n            }\n\n            function filterData(data, type, value) {\n                return data.filter(item => {\n                    if (type === 'cms') {\n                        return item.CmsList.split(';').map(cms => cms.trim()).includes(value);\n                    } else if (type === 'other') {\n                        return item.OtherList.split(';').map(other => other.trim()).includes(value);\n                    }\n                    return false;\n                });\n            }\n\n            function updateTable(data) {\n                const tableBody = document.querySelector(\"tbody\");\n                tableBody.innerHTML = ”;\n                data.forEach(item => {\n                    const row = document.createElement('tr');\n                    row.innerHTML = `\n                        <td class=\"container\">\n                            <div class=\"left\">\n                                <p><strong>目标:</strong> <a href=\"${item.Url}\" target=\"_blank\">${item.Url}</a></p>\n                                <p><strong>状态码:</strong> ${item.StatusCode}</p>\n                                <p><strong>标题:</strong> ${item.Title}</p>\n                                <p><strong>CMS指纹信息:</strong> <span class=\"cms-info\">${item.CmsList}</span></p>\n                                <p><strong>OTHER信息:</strong> <span class=\"other-info\">${item.OtherList}</span></p>\n                            </div>\n                            <div class=\"right\">\n                                ${item.Screenshot ? `<img src=\"${item.Screenshot}\" alt=\"Screenshot\" onclick=\"openModal('${item.Screenshot}')\" loading=\"lazy\">` : `<p>No Screenshot</p>`}\n                            </div>\n                        </td>\n                    `;\n                    tableBody.appendChild(row);\n                });\n            }\n\n            function updateAllButton(data) {\n                const allCount = data.length;\n                const allButton = document.getElementById('btn-all');\n                allButton.textContent = `ALL (${allCount})`;\n            }\n\n            document.addEventListener(\"click\", function(event) {\n                if (event.target.classList.contains('cms-item') || event.target.classList.contains('other-item')) {\n                    const type = event.target.getAttribute('data-type');\n                    const value = event.target.getAttribute('data-value');\n                    const filteredData = filterData(originalData, type, value);\n                    updateTable(filteredData);\n                } else if (event.target.id === 'btn-all') {\n                    updateTable(originalData);\n                }\n            });\n\n            fetch('"
 //var HtmlHeaderB = "')\n                .then(response => {\n                    if (!response.ok) {\n                        throw new Error('Network response was not ok');\n                    }\n                    return response.json();\n                })\n                .then(data => {\n                    originalData = data;\n                    updateStats(data);\n                    updateTable(data);\n                    updateAllButton(data);\n                })\n                .catch(error => console.error('Error loading JSON data:', error));\n        });\n    </script>\n</head>\n<body>\n    <h1>URL Fingerprint Report</h1>\n    <div class=\"stats\">\n        <div id=\"cms-stats\"></div>\n        <div id=\"other-stats\"></div>\n        <div id=\"all-stats\"></div>\n    </div>\n    <div id=\"modal\" class=\"modal\">\n        <div class=\"modal-content\">\n            <span class=\"modal-close\">&times;</span>\n            <img id=\"modal-img\" src=\"\" alt=\"Screenshot\">\n        </div>\n    </div>\n    <table>\n        <thead>\n            <tr>\n                <th>Details</th>\n            </tr>\n        </thead>\n        <tbody>\n            <!-- Data rows will be inserted here by JavaScript -->\n        </tbody>\n    </table>\n    <button id=\"scroll-to-top\" title=\"Go to Top\">&#8679;</button>\n</body>\n</html>\n"
 
-var HtmlHeaderA = "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n    <meta charset=\"UTF-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n    <title>httpgo Fingerprint Report</title>\n    <style>\n        body {\n            font-family: Arial, sans-serif;\n            margin: 0;\n            padding: 0;\n            background-color: #f4f4f4;\n            color: #333;\n        }\n        h1 {\n            text-align: center;\n            margin: 20px 0;\n            color: #444;\n        }\n        table {\n            width: 90%;\n            margin: 20px auto;\n            border-collapse: collapse;\n            background: #fff;\n            box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);\n        }\n        table, th, td {\n            border: 1px solid #ddd;\n        }\n        th, td {\n            padding: 12px;\n            text-align: left;\n        }\n        th {\n            background-color: #f8f8f8;\n            color: #555;\n        }\n        .container {\n            display: flex;\n            justify-content: space-between;\n            align-items: flex-start;\n            padding: 10px;\n        }\n        .left {\n            flex: 1;\n            margin-right: 20px;\n            background: #fafafa;\n            padding: 15px;\n            border-radius: 8px;\n            box-shadow: 0 2px 5px rgba(0, 0, 0, 0.1);\n            max-width: 50%;\n        }\n        .right {\n            flex: 1;\n            max-width: 50%;\n            text-align: center;\n        }\n        .right img {\n            width: 40%;\n            height: auto;\n            border-radius: 8px;\n            cursor: pointer;\n            transition: opacity 0.3s;\n        }\n        .right img:hover {\n            opacity: 0.8;\n        }\n        .modal {\n            display: none;\n            position: fixed;\n            top: 0;\n            left: 0;\n            width: 100%;\n            height: 100%;\n            background-color: rgba(0, 0, 0, 0.8);\n            align-items: center;\n            justify-content: center;\n            z-index: 1000;\n        }\n        .modal-content {\n            max-width: 90%;\n            max-height: 90%;\n            position: relative;\n        }\n        .modal-content img {\n            width: 100%;\n            height: auto;\n            border: 5px solid #fff;\n            border-radius: 8px;\n        }\n        .modal-close {\n            position: absolute;\n            top: 20px;\n            right: 20px;\n            font-size: 2rem;\n            color: #fff;\n            cursor: pointer;\n            transition: color 0.3s;\n        }\n        .modal-close:hover {\n            color: #ddd;\n        }\n        .cms-info {\n            color: red;\n        }\n        .other-info {\n            color: green;\n        }\n        .stats {\n            margin: 20px auto;\n            width: 90%;\n            padding: 15px;\n            background: #fafafa;\n            border-radius: 8px;\n            box-shadow: 0 2px 5px rgba(0, 0, 0, 0.1);\n        }\n        .stats h2 {\n            margin-top: 0;\n            font-size: 1.2rem; /* 调整大小 */\n        }\n        .stats ul {\n            list-style: none;\n            padding: 0;\n            margin: 0;\n        }\n        .stats ul li {\n            margin: 5px 0;\n            font-size: 1rem; /* 调整大小 */\n        }\n        .button-group {\n            display: flex;\n            flex-wrap: wrap;\n            /* justify-content: center; */\n            margin: 20px 0;\n        }\n        .button-group button {\n            background-color: #007bff;\n            color: white;\n            border: none;\n            padding: 6px 12px; /* 减少内边距 */\n            margin: 4px; /* 减少外边距 */\n            border-radius: 4px; /* 减小圆角 */\n            cursor: pointer;\n            transition: background-color 0.3s;\n            font-size: 0.875rem; /* 调整字体大小 */\n        }\n\n        .button-group button:hover {\n            background-color: #0056b3;\n        }\n\n        #scroll-to-top {\n            position: fixed;\n            bottom: 20px;\n            right: 20px;\n            background-color: #007bff;\n            color: white;\n            border: none;\n            border-radius: 50%;\n            width: 40px; /* 减少宽度 */\n            height: 40px; /* 减少高度 */\n            display: flex;\n            align-items: center;\n            justify-content: center;\n            cursor: pointer;\n            font-size: 18px; /* 调整字体大小 */\n            box-shadow: 0 4px 8px rgba(0, 0, 0, 0.2);\n            transition: background-color 0.3s, box-shadow 0.3s;\n        }\n        \n        #scroll-to-top:hover {\n            background-color: #0056b3;\n            box-shadow: 0 6px 12px rgba(0, 0, 0, 0.3);\n        }\n\n    </style>\n    <script>\n        document.addEventListener(\"DOMContentLoaded\", function() {\n        const scrollToTopButton = document.getElementById(\"scroll-to-top\");\n                \n        scrollToTopButton.addEventListener(\"click\", function() {\n            window.scrollTo({\n                top: 0,\n                behavior: \"smooth\"\n            });\n        });\n        \n        // Show or hide the button based on scroll position\n        window.addEventListener(\"scroll\", function() {\n            if (window.scrollY > 300) {\n                scrollToTopButton.style.display = \"flex\";\n            } else {\n                scrollToTopButton.style.display = \"none\";\n            }\n        });\n        });\n\n        document.addEventListener(\"DOMContentLoaded\", function() {\n            let originalData = [];\n\n            function openModal(src) {\n                var modal = document.getElementById(\"modal\");\n                var modalImg = document.getElementById(\"modal-img\");\n                modal.style.display = \"flex\";\n                modalImg.src = src;\n            }\n\n            function closeModal(event) {\n                if (event.target === document.getElementById(\"modal\")) {\n                    document.getElementById(\"modal\").style.display = \"none\";\n                }\n            }\n\n            function updateStats(data) {\n                const cmsCount = {};\n                const otherCount = {};\n                const statusCodeCount = {};\n\n                data.forEach(item => {\n                    item.CmsList.split(';').forEach(cms => {\n                        cms = cms.trim();\n                        if (cms) {\n                            cmsCount[cms] = (cmsCount[cms] || 0) + 1;\n                        }\n                    });\n\n                    item.OtherList.split(';').forEach(other => {\n                        other = other.trim();\n                        if (other) {\n                            otherCount[other] = (otherCount[other] || 0) + 1;\n                        }\n                    });\n\n                    const statusCode = item.StatusCode;\n                    if (statusCode) {\n                        statusCodeCount[statusCode] = (statusCodeCount[statusCode] || 0) + 1;\n                    }\n                });\n\n                const cmsStats = Object.entries(cmsCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"cms-item\" data-type=\"cms\" data-value=\"${key}\">${key}: ${value}</button>`)\n                    .join('');\n                document.getElementById('cms-stats').innerHTML = `<h2>CMS Fingerprint Information</h2><div class=\"button-group\">${cmsStats}</div>`;\n\n                const otherStats = Object.entries(otherCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"other-item\" data-type=\"other\" data-value=\"${key}\">${key}: ${value}</button>`)\n                    .join('');\n                document.getElementById('other-stats').innerHTML = `<br><h2>Other Fingerprint Information</h2><div class=\"button-group\">${otherStats}</div>`;\n\n                const statusCodeStats = Object.entries(statusCodeCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"status-code-item\" data-type=\"status-code\" data-value=\"${key}\">${key}: ${value}</button>`)\n                    .join('');\n                document.getElementById('status-code-stats').innerHTML = `<br><h2>Status Code Information</h2><div class=\"button-group\">${statusCodeStats}</div>`;\n\n                document.getElementById('all-stats').innerHTML = `<br><h2>All Fingerprint Information</h2><div class=\"button-group\"><button id=\"btn-all\">ALL</button></div>`;\n            }\n\n            function filterData(data, type, value) {\n                return data.filter(item => {\n                    if (type === 'cms') {\n                        return item.CmsList.split(';').map(cms => cms.trim()).includes(value);\n                    } else if (type === 'other') {\n                        return item.OtherList.split(';').map(other => other.trim()).includes(value);\n                    } else if (type === 'status-code') {\n                        return item.StatusCode.toString() === value;\n                    }\n                    return false;\n                });\n            }\n\n            function updateTable(data) {\n                const tableBody = document.querySelector(\"tbody\");\n                tableBody.innerHTML = '';\n                data.forEach(item => {\n                    const row = document.createElement('tr');\n                    row.innerHTML = `\n                        <td class=\"container\">\n                            <div class=\"left\">\n                                <p><strong>目标:</strong> <a href=\"${item.Url}\" target=\"_blank\">${item.Url}</a></p>\n                                <p><strong>状态码:</strong> ${item.StatusCode}</p>\n                                <p><strong>标题:</strong> ${item.Title}</p>\n                                <p><strong>CMS指纹信息:</strong> <span class=\"cms-info\">${item.CmsList}</span></p>\n                                <p><strong>OTHER信息:</strong> <span class=\"other-info\">${item.OtherList}</span></p>\n                            </div>\n                            <div class=\"right\">\n                                ${item.Screenshot ? `<img src=\"${item.Screenshot}\" alt=\"Screenshot\" onclick=\"openModal('${item.Screenshot}')\" loading=\"lazy\">` : `<p>No Screenshot</p>`}\n                            </div>\n                        </td>\n                    `;\n                    tableBody.appendChild(row);\n                });\n            }\n\n            function updateAllButton(data) {\n                const allCount = data.length;\n                const allButton = document.getElementById('btn-all');\n                allButton.textContent = `ALL (${allCount})`;\n            }\n\n            document.addEventListener(\"click\", function(event) {\n                if (event.target.classList.contains('cms-item') || event.target.classList.contains('other-item') || event.target.classList.contains('status-code-item')) {\n                    const type = event.target.getAttribute('data-type');\n                    const value = event.target.getAttribute('data-value');\n                    const filteredData = filterData(originalData, type, value);\n                    updateTable(filteredData);\n                } else if (event.target.id === 'btn-all') {\n                    updateTable(originalData);\n                }\n            });\n\n            fetch('"
-var HtmlHeaderB = "')\n                .then(response => {\n                    if (!response.ok) {\n                        throw new Error('Network response was not ok');\n                    }\n                    return response.json();\n                })\n                .then(data => {\n                    originalData = data;\n                    updateStats(data);\n                    updateTable(data);\n                    updateAllButton(data);\n                })\n                .catch(error => console.error('Error loading JSON data:', error));\n        });\n    </script>\n</head>\n<body>\n    <h1>URL Fingerprint Report</h1>\n    <div class=\"stats\">\n        <div id=\"cms-stats\"></div>\n        <div id=\"other-stats\"></div>\n        <div id=\"status-code-stats\"></div>\n        <div id=\"all-stats\"></div>\n    </div>\n    <div id=\"modal\" class=\"modal\">\n        <div class=\"modal-content\">\n            <span class=\"modal-close\">&times;</span>\n            <img id=\"modal-img\" src=\"\" alt=\"Screenshot\">\n        </div>\n    </div>\n    <table>\n        <thead>\n            <tr>\n                <th>Details</th>\n            </tr>\n        </thead>\n        <tbody>\n            <!-- Data rows will be inserted here by JavaScript -->\n        </tbody>\n    </table>\n    <button id=\"scroll-to-top\" title=\"Go to Top\">&#8679;</button>\n</body>\n</html>\n"
+var HtmlHeaderA = "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n    <meta charset=\"UTF-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n    <title>httpgo Fingerprint Report</title>\n    <style>\n        body {\n            font-family: Arial, sans-serif;\n            margin: 0;\n            padding: 0;\n            background-color: #f4f4f4;\n            color: #333;\n        }\n        h1 {\n            text-align: center;\n            margin: 20px 0;\n            color: #444;\n        }\n        table {\n            width: 90%;\n            margin: 20px auto;\n            border-collapse: collapse;\n            background: #fff;\n            box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);\n        }\n        table, th, td {\n            border: 1px solid #ddd;\n        }\n        th, td {\n            padding: 12px;\n            text-align: left;\n        }\n        th {\n            background-color: #f8f8f8;\n            color: #555;\n        }\n        .container {\n            display: flex;\n            justify-content: space-between;\n            align-items: flex-start;\n            padding: 10px;\n        }\n        .left {\n            flex: 1;\n            margin-right: 20px;\n            background: #fafafa;\n            padding: 15px;\n            border-radius: 8px;\n            box-shadow: 0 2px 5px rgba(0, 0, 0, 0.1);\n            max-width: 50%;\n        }\n        .right {\n            flex: 1;\n            max-width: 50%;\n            text-align: center;\n        }\n        .right img {\n            width: 40%;\n            height: auto;\n            border-radius: 8px;\n            cursor: pointer;\n            transition: opacity 0.3s;\n        }\n        .right img:hover {\n            opacity: 0.8;\n        }\n        .modal {\n            display: none;\n            position: fixed;\n            top: 0;\n            left: 0;\n            width: 100%;\n            height: 100%;\n            background-color: rgba(0, 0, 0, 0.8);\n            align-items: center;\n            justify-content: center;\n            z-index: 1000;\n        }\n        .modal-content {\n            max-width: 90%;\n            max-height: 90%;\n            position: relative;\n        }\n        .modal-content img {\n            width: 100%;\n            height: auto;\n            border: 5px solid #fff;\n            border-radius: 8px;\n        }\n        .modal-close {\n            position: absolute;\n            top: 20px;\n            right: 20px;\n            font-size: 2rem;\n            color: #fff;\n            cursor: pointer;\n            transition: color 0.3s;\n        }\n        .modal-close:hover {\n            color: #ddd;\n        }\n        .cms-info {\n            color: red;\n        }\n        .other-info {\n            color: green;\n        }\n        .stats {\n            margin: 20px auto;\n            width: 90%;\n            padding: 15px;\n            background: #fafafa;\n            border-radius: 8px;\n            box-shadow: 0 2px 5px rgba(0, 0, 0, 0.1);\n        }\n        .stats h2 {\n            margin-top: 0;\n            font-size: 1.2rem; /* 调整大小 */\n        }\n        .stats ul {\n            list-style: none;\n            padding: 0;\n            margin: 0;\n        }\n        .stats ul li {\n            margin: 5px 0;\n            font-size: 1rem; /* 调整大小 */\n        }\n        .button-group {\n            display: flex;\n            flex-wrap: wrap;\n            /* justify-content: center; */\n            margin: 20px 0;\n        }\n        .button-group button {\n            background-color: #007bff;\n            color: white;\n            border: none;\n            padding: 6px 12px; /* 减少内边距 */\n            margin: 4px; /* 减少外边距 */\n            border-radius: 4px; /* 减小圆角 */\n            cursor: pointer;\n            transition: background-color 0.3s;\n            font-size: 0.875rem; /* 调整字体大小 */\n        }\n\n        .button-group button:hover {\n            background-color: #0056b3;\n        }\n\n        #scroll-to-top {\n            position: fixed;\n            bottom: 20px;\n            right: 20px;\n            background-color: #007bff;\n            color: white;\n            border: none;\n            border-radius: 50%;\n            width: 40px; /* 减少宽度 */\n            height: 40px; /* 减少高度 */\n            display: flex;\n            align-items: center;\n            justify-content: center;\n            cursor: pointer;\n            font-size: 18px; /* 调整字体大小 */\n            box-shadow: 0 4px 8px rgba(0, 0, 0, 0.2);\n            transition: background-color 0.3s, box-shadow 0.3s;\n        }\n        \n        #scroll-to-top:hover {\n            background-color: #0056b3;\n            box-shadow: 0 6px 12px rgba(0, 0, 0, 0.3);\n        }\n\n    </style>\n    <script>\n        document.addEventListener(\"DOMContentLoaded\", function() {\n        const scrollToTopButton = document.getElementById(\"scroll-to-top\");\n                \n        scrollToTopButton.addEventListener(\"click\", function() {\n            window.scrollTo({\n                top: 0,\n                behavior: \"smooth\"\n            });\n        });\n        \n        // Show or hide the button based on scroll position\n        window.addEventListener(\"scroll\", function() {\n            if (window.scrollY > 300) {\n                scrollToTopButton.style.display = \"flex\";\n            } else {\n                scrollToTopButton.style.display = \"none\";\n            }\n        });\n        });\n\n        document.addEventListener(\"DOMContentLoaded\", function() {\n            let originalData = [];\n\n            function openModal(src) {\n                var modal = document.getElementById(\"modal\");\n                var modalImg = document.getElementById(\"modal-img\");\n                modal.style.display = \"flex\";\n                modalImg.src = src;\n            }\n\n            function closeModal(event) {\n                if (event.target === document.getElementById(\"modal\")) {\n                    document.getElementById(\"modal\").style.display = \"none\";\n                }\n            }\n\n            // escapeHtml 转义扫描目标返回的内容（标题、证书、跳转地址等），防止在报告页面中执行脚本\n            function escapeHtml(value) {\n                return String(value ?? '').replace(/[&<>\"']/g, c => ({'&': '&amp;', '<': '&lt;', '>': '&gt;', '\"': '&quot;', \"'\": '&#39;'})[c]);\n            }\n\n            function updateStats(data) {\n                const cmsCount = {};\n                const otherCount = {};\n                const statusCodeCount = {};\n                const tlsIssueCount = {};\n\n                data.forEach(item => {\n                    item.CmsList.split(';').forEach(cms => {\n                        cms = cms.trim();\n                        if (cms) {\n                            cmsCount[cms] = (cmsCount[cms] || 0) + 1;\n                        }\n                    });\n\n                    item.OtherList.split(';').forEach(other => {\n                        other = other.trim();\n                        if (other) {\n                            otherCount[other] = (otherCount[other] || 0) + 1;\n                        }\n                    });\n\n                    const statusCode = item.StatusCode;\n                    if (statusCode) {\n                        statusCodeCount[statusCode] = (statusCodeCount[statusCode] || 0) + 1;\n                    }\n\n                    tlsIssueTypes(item).forEach(issue => {\n                        tlsIssueCount[issue] = (tlsIssueCount[issue] || 0) + 1;\n                    });\n                });\n\n                const cmsStats = Object.entries(cmsCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"cms-item\" data-type=\"cms\" data-value=\"${escapeHtml(key)}\">${escapeHtml(key)}: ${value}</button>`)\n                    .join('');\n                document.getElementById('cms-stats').innerHTML = `<h2>CMS Fingerprint Information</h2><div class=\"button-group\">${cmsStats}</div>`;\n\n                const otherStats = Object.entries(otherCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"other-item\" data-type=\"other\" data-value=\"${escapeHtml(key)}\">${escapeHtml(key)}: ${value}</button>`)\n                    .join('');\n                document.getElementById('other-stats').innerHTML = `<br><h2>Other Fingerprint Information</h2><div class=\"button-group\">${otherStats}</div>`;\n\n                const statusCodeStats = Object.entries(statusCodeCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"status-code-item\" data-type=\"status-code\" data-value=\"${escapeHtml(key)}\">${escapeHtml(key)}: ${value}</button>`)\n                    .join('');\n                document.getElementById('status-code-stats').innerHTML = `<br><h2>Status Code Information</h2><div class=\"button-group\">${statusCodeStats}</div>`;\n\n                const tlsIssueStats = Object.entries(tlsIssueCount).sort((a, b) => b[1] - a[1])\n                    .map(([key, value]) => `<button class=\"tls-issue-item\" data-type=\"tls-issue\" data-value=\"${escapeHtml(key)}\">${escapeHtml(key)}: ${value}</button>`)\n                    .join('');\n                document.getElementById('tls-stats').innerHTML = tlsIssueStats ? `<br><h2>TLS Issues</h2><div class=\"button-group\">${tlsIssueStats}</div>` : ``;\n\n                document.getElementById('all-stats').innerHTML = `<br><h2>All Fingerprint Information</h2><div class=\"button-group\"><button id=\"btn-all\">ALL</button></div>`;\n            }\n\n            function tlsIssueTypes(item) {\n                return (item.TLSIssues || '').split(';').map(issue => issue.split('(')[0].trim()).filter(issue => issue);\n            }\n\n            function filterData(data, type, value) {\n                return data.filter(item => {\n                    if (type === 'cms') {\n                        return item.CmsList.split(';').map(cms => cms.trim()).includes(value);\n                    } else if (type === 'other') {\n                        return item.OtherList.split(';').map(other => other.trim()).includes(value);\n                    } else if (type === 'status-code') {\n                        return item.StatusCode.toString() === value;\n                    } else if (type === 'tls-issue') {\n                        return tlsIssueTypes(item).includes(value);\n                    }\n                    return false;\n                });\n            }\n\n            function updateTable(data) {\n                const tableBody = document.querySelector(\"tbody\");\n                tableBody.innerHTML = '';\n                data.forEach(item => {\n                    const row = document.createElement('tr');\n                    row.innerHTML = `\n                        <td class=\"container\">\n                            <div class=\"left\">\n                                <p><strong>目标:</strong> <a href=\"${escapeHtml(item.Url)}\" target=\"_blank\">${escapeHtml(item.Url)}</a></p>\n                                <p><strong>状态码:</strong> ${item.StatusCode}</p>\n                                <p><strong>标题:</strong> ${escapeHtml(item.Title)}</p>\n                                <p><strong>CMS指纹信息:</strong> <span class=\"cms-info\">${escapeHtml(item.CmsList)}</span></p>\n                                <p><strong>OTHER信息:</strong> <span class=\"other-info\">${escapeHtml(item.OtherList)}</span></p>\n                                ${item.Extracted ? `<p><strong>提取信息:</strong> ${escapeHtml(item.Extracted)}</p>` : ``}\n                                ${item.ErrorClass ? `<p><strong>错误类型:</strong> ${escapeHtml(item.ErrorClass)}</p>` : ``}\n                                ${item.Error ? `<p><strong>错误原因:</strong> ${escapeHtml(item.Error)}</p>` : ``}\n                                ${item.TotalMs !== undefined ? `<p><strong>耗时:</strong> DNS ${item.DNSMs}ms / 连接 ${item.ConnectMs}ms / TLS ${item.TLSMs}ms / 首字节 ${item.TTFBMs}ms / 总计 ${item.TotalMs}ms</p>` : ``}\n                                ${item.TLSVersion ? `<p><strong>TLS:</strong> ${escapeHtml(item.TLSVersion)} ${escapeHtml(item.TLSCipher)}</p>` : ``}\n                                ${item.CertSHA256 ? `<p><strong>证书:</strong> CN: ${escapeHtml(item.CertCN)} / SAN: ${escapeHtml(item.CertSAN)} / 签发者: ${escapeHtml(item.CertIssuer)} / 到期时间: ${escapeHtml(item.CertNotAfter)}</p>` : ``}\n                                ${item.Protocol ? `<p><strong>协议:</strong> ${escapeHtml(item.Protocol)}${item.AltSvc ? ` (Alt-Svc: ${escapeHtml(item.AltSvc)})` : ``}</p>` : ``}\n                                ${item.JARM ? `<p><strong>JARM:</strong> ${escapeHtml(item.JARM)}</p>` : ``}\n                                ${item.TLSIssues ? `<p><strong>TLS问题:</strong> <span class=\"cms-info\">${escapeHtml(item.TLSIssues)}</span></p>` : ``}\n                                ${item.RedirectChain ? `<p><strong>最终地址:</strong> ${escapeHtml(item.FinalUrl)}</p>\n                                <p><strong>跳转链:</strong> ${escapeHtml(item.RedirectChain)}</p>` : ``}\n                            </div>\n                            <div class=\"right\">\n                                ${item.Screenshot ? `<img src=\"${escapeHtml(item.Screenshot)}\" alt=\"Screenshot\" onclick=\"openModal(this.src)\" loading=\"lazy\">` : `<p>No Screenshot</p>`}\n                            </div>\n                        </td>\n                    `;\n                    tableBody.appendChild(row);\n                });\n            }\n\n            function updateAllButton(data) {\n                const allCount = data.length;\n                const allButton = document.getElementById('btn-all');\n                allButton.textContent = `ALL (${allCount})`;\n            }\n\n            document.addEventListener(\"click\", function(event) {\n                if (event.target.classList.contains('cms-item') || event.target.classList.contains('other-item') || event.target.classList.contains('status-code-item') || event.target.classList.contains('tls-issue-item')) {\n                    const type = event.target.getAttribute('data-type');\n                    const value = event.target.getAttribute('data-value');\n                    const filteredData = filterData(originalData, type, value);\n                    updateTable(filteredData);\n                } else if (event.target.id === 'btn-all') {\n                    updateTable(originalData);\n                }\n            });\n\n            fetch('"
+var HtmlHeaderB = "')\n                .then(response => {\n                    if (!response.ok) {\n                        throw new Error('Network response was not ok');\n                    }\n                    return response.json();\n                })\n                .then(data => {\n                    originalData = data;\n                    updateStats(data);\n                    updateTable(data);\n                    updateAllButton(data);\n                })\n                .catch(error => console.error('Error loading JSON data:', error));\n        });\n    </script>\n</head>\n<body>\n    <h1>URL Fingerprint Report</h1>\n    <div class=\"stats\">\n        <div id=\"cms-stats\"></div>\n        <div id=\"other-stats\"></div>\n        <div id=\"status-code-stats\"></div>\n        <div id=\"tls-stats\"></div>\n        <div id=\"all-stats\"></div>\n    </div>\n    <div id=\"modal\" class=\"modal\">\n        <div class=\"modal-content\">\n            <span class=\"modal-close\">&times;</span>\n            <img id=\"modal-img\" src=\"\" alt=\"Screenshot\">\n        </div>\n    </div>\n    <table>\n        <thead>\n            <tr>\n                <th>Details</th>\n            </tr>\n        </thead>\n        <tbody>\n            <!-- Data rows will be inserted here by JavaScript -->\n        </tbody>\n    </table>\n    <button id=\"scroll-to-top\" title=\"Go to Top\">&#8679;</button>\n</body>\n</html>\n"
 
 // 创建 HTML 报告
 func InitializeHTMLReport(filename string, json string) (*os.File, error) {
@@ -50,49 +69,3 @@ func InitializeHTMLReport(filename string, json string) (*os.File, error) {
 //	// 生成新的 JSON 文件名
 //	return filepath.Join(filepath.Dir(filePath), nameWithoutExt+".json")
 //}
-
-// 定义全局互斥锁
-var jsonMutex sync.Mutex
-
-// AppendJSONReport 将 URLFingerprint 数据追加到指定的 JSON 文件中
-func AppendJSONReport(filename string, data URLFingerprint) error {
-	// 使用互斥锁确保线程安全
-	jsonMutex.Lock()
-	defer jsonMutex.Unlock()
-
-	var existingData []URLFingerprint
-
-	// 读取现有文件内容
-	fileContent, err := os.ReadFile(filename)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("无法读取 JSON 文件: %v", err)
-	}
-
-	// 如果文件存在且有内容，解码现有数据
-	if len(fileContent) > 0 {
-		if err := json.Unmarshal(fileContent, &existingData); err != nil {
-			return fmt.Errorf("无法解码 JSON 内容: %v", err)
-		}
-	}
-
-	// 添加新数据
-	existingData = append(existingData, data)
-
-	// 打开文件进行写入（覆盖模式）
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return fmt.Errorf("无法打开 JSON 文件: %v", err)
-	}
-	defer file.Close()
-
-	// 创建 JSON 编码器并设置缩进
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "    ")
-
-	// 写入数据
-	if err := encoder.Encode(existingData); err != nil {
-		return fmt.Errorf("无法写入 JSON 数据: %v", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// numericFields 报告中由本程序生成的数字字段，无需转义
+var numericFields = map[string]bool{"StatusCode": true, "DNSMs": true, "ConnectMs": true, "TLSMs": true, "TTFBMs": true, "TotalMs": true}
+
+func TestHTMLReportEscapes(t *testing.T) {
+	// 标题、证书、跳转地址等字段来自扫描目标，插入页面前必须转义
+	for _, m := range regexp.MustCompile(`\$\{item\.(\w+)\}`).FindAllStringSubmatch(HtmlHeaderA, -1) {
+		if !numericFields[m[1]] {
+			t.Errorf("%s is inserted without escapeHtml", m[1])
+		}
+	}
+	if strings.Contains(HtmlHeaderA, "${key}") {
+		t.Error("fingerprint names in the stats buttons are inserted without escapeHtml")
+	}
+	for _, field := range []string{"Title", "CertCN", "CertSAN", "CertIssuer", "Error", "RedirectChain", "FinalUrl", "Extracted", "AltSvc", "TLSIssues"} {
+		if !strings.Contains(HtmlHeaderA, "${escapeHtml(item."+field+")}") {
+			t.Errorf("%s is not shown in the report", field)
+		}
+	}
+}
+
+func TestEscapeHTMLFunction(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found")
+	}
+	fn := regexp.MustCompile(`(?s)function escapeHtml\(value\) \{.*?\n            \}`).FindString(HtmlHeaderA)
+	if fn == "" {
+		t.Fatal("escapeHtml not found in the report template")
+	}
+	script := fn + `
+for (const v of ['<img src=x onerror="alert(1)">', "a&b 'c'", null, undefined, 42]) {
+    console.log(escapeHtml(v));
+}`
+	out, err := exec.Command(node, "-e", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	want := "&lt;img src=x onerror=&quot;alert(1)&quot;&gt;\na&amp;b &#39;c&#39;\n\n\n42\n"
+	if string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParsePorts 解析端口列表，如 80,443,8080-8090，返回去重后的升序端口
+func ParsePorts(s string) ([]int, error) {
+	seen := make(map[int]struct{})
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			lo, hi = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+		start, err := strconv.Atoi(lo)
+		if err != nil || start < 1 || start > 65535 {
+			return nil, fmt.Errorf("invalid port '%s'", part)
+		}
+		end, err := strconv.Atoi(hi)
+		if err != nil || end < start || end > 65535 {
+			return nil, fmt.Errorf("invalid port range '%s'", part)
+		}
+		for p := start; p <= end; p++ {
+			seen[p] = struct{}{}
+		}
+	}
+
+	ports := make([]int, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// ExpandTargets 逐行读取目标并展开，每得到一个目标调用一次 yield，yield 返回 false 时停止。
+// 展开过程不会把全部目标放入内存，适合大网段与多端口组合。
+func ExpandTargets(r io.Reader, ports []int, yield func(target string) bool) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !ExpandTarget(line, ports, yield) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// ExpandTarget 展开单个目标：
+// 带协议的url原样输出；CIDR（10.1.0.0/22）与IP范围（10.1.0.1-10.1.0.200、10.1.0.1-200）展开为每个IP；
+// 不带端口的主机与 ports 中的每个端口组合。yield 返回 false 时停止并返回 false。
+func ExpandTarget(target string, ports []int, yield func(target string) bool) bool {
+	if strings.Contains(target, "://") {
+		return yield(target)
+	}
+
+	withPorts := func(host string) bool {
+		if len(ports) == 0 {
+			return yield(host)
+		}
+		for _, p := range ports {
+			if !yield(net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(p))) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if start, end, ok := parseIPRange(target); ok {
+		for ip := start; ; ip = nextIP(ip) {
+			if !withPorts(ip.String()) {
+				return false
+			}
+			if ip.Equal(end) {
+				return true
+			}
+		}
+	}
+
+	// 已指定端口或路径的目标不再组合端口
+	if strings.ContainsAny(target, "/?#") {
+		return yield(target)
+	}
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return yield(target)
+	}
+	return withPorts(target)
+}
+
+// parseIPRange 解析CIDR或IP范围，返回首尾IP，不是IP范围时 ok 为 false
+func parseIPRange(s string) (start, end net.IP, ok bool) {
+	if strings.Contains(s, "/") {
+		ip, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, nil, false
+		}
+		start = ipnet.IP
+		end = make(net.IP, len(start))
+		for i := range start {
+			end[i] = start[i] | ^ipnet.Mask[i]
+		}
+		// IPv4 网段去掉网络地址和广播地址
+		if ones, bits := ipnet.Mask.Size(); ip.To4() != nil && bits-ones >= 2 {
+			start, end = nextIP(start), prevIP(end)
+		}
+		return start, end, true
+	}
+
+	i := strings.Index(s, "-")
+	if i < 0 {
+		return nil, nil, false
+	}
+	start = net.ParseIP(strings.TrimSpace(s[:i]))
+	if start == nil {
+		return nil, nil, false
+	}
+	rest := strings.TrimSpace(s[i+1:])
+	end = net.ParseIP(rest)
+	if end == nil {
+		// 简写形式 10.1.0.1-200，只替换最后一段
+		v4 := start.To4()
+		n, err := strconv.Atoi(rest)
+		if v4 == nil || err != nil || n < 0 || n > 255 {
+			return nil, nil, false
+		}
+		end = net.IPv4(v4[0], v4[1], v4[2], byte(n))
+	}
+
+	if v4 := start.To4(); v4 != nil {
+		start = v4
+		if end = end.To4(); end == nil {
+			return nil, nil, false
+		}
+	} else if end.To4() != nil {
+		return nil, nil, false
+	}
+	if compareIP(start, end) > 0 {
+		return nil, nil, false
+	}
+	return start, end, true
+}
+
+// nextIP 返回下一个IP
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// prevIP 返回上一个IP
+func prevIP(ip net.IP) net.IP {
+	prev := make(net.IP, len(ip))
+	copy(prev, ip)
+	for i := len(prev) - 1; i >= 0; i-- {
+		prev[i]--
+		if prev[i] != 0xff {
+			break
+		}
+	}
+	return prev
+}
+
+// compareIP 比较两个长度相同的IP
+func compareIP(a, b net.IP) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
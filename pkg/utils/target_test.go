@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{in: "80", want: []int{80}},
+		{in: "443, 80,80", want: []int{80, 443}},
+		{in: "8080-8083,80", want: []int{80, 8080, 8081, 8082, 8083}},
+		{in: "8080 - 8081", want: []int{8080, 8081}},
+		{in: "", want: []int{}},
+		{in: "90-80", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "65536", wantErr: true},
+		{in: "80-70000", wantErr: true},
+		{in: "http", wantErr: true},
+		{in: "80-", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePorts(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePorts(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePorts(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePorts(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// expand 展开单个目标的全部结果
+func expand(target string, ports []int) []string {
+	var got []string
+	ExpandTarget(target, ports, func(t string) bool {
+		got = append(got, t)
+		return true
+	})
+	return got
+}
+
+func TestExpandTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		ports  []int
+		want   []string
+	}{
+		{"url", "https://example.com:8443/a", []int{80}, []string{"https://example.com:8443/a"}},
+		{"host", "example.com", nil, []string{"example.com"}},
+		{"host with ports", "example.com", []int{80, 443}, []string{"example.com:80", "example.com:443"}},
+		{"host with port", "example.com:8080", []int{80, 443}, []string{"example.com:8080"}},
+		{"ipv6 with port", "[::1]:8080", []int{80}, []string{"[::1]:8080"}},
+		{"ipv6 with ports", "[::1]", []int{80}, []string{"[::1]:80"}},
+		{"host with path", "example.com/admin", []int{80}, []string{"example.com/admin"}},
+		{"cidr /30 trims network and broadcast", "10.1.0.0/30", nil, []string{"10.1.0.1", "10.1.0.2"}},
+		{"cidr /31 keeps both", "10.1.0.0/31", nil, []string{"10.1.0.0", "10.1.0.1"}},
+		{"cidr /32", "10.1.0.5/32", nil, []string{"10.1.0.5"}},
+		{"cidr with ports", "10.1.0.0/30", []int{80, 443}, []string{"10.1.0.1:80", "10.1.0.1:443", "10.1.0.2:80", "10.1.0.2:443"}},
+		{"full range", "10.1.0.254-10.1.1.1", nil, []string{"10.1.0.254", "10.1.0.255", "10.1.1.0", "10.1.1.1"}},
+		{"short range", "10.1.0.1-3", nil, []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"}},
+		{"short range with ports", "10.1.0.1-2", []int{8080}, []string{"10.1.0.1:8080", "10.1.0.2:8080"}},
+		{"reversed range is a host", "10.1.0.9-3", nil, []string{"10.1.0.9-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expand(tt.target, tt.ports); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandTarget(%q, %v) = %v, want %v", tt.target, tt.ports, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTargetsStop(t *testing.T) {
+	var got []string
+	err := ExpandTargets(strings.NewReader("10.0.0.0/24\n\nexample.com\n"), nil, func(target string) bool {
+		got = append(got, target)
+		return len(got) < 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}